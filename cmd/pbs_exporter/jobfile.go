@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/0nebody/pbs_exporter/internal/pbsjob"
+)
+
+// Writes the job file as JSON; raw lists every attribute in the job file
+// rather than those mapped onto the job.
+func decodeJobFile(w io.Writer, path string, raw bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening job file: %w", err)
+	}
+	defer f.Close()
+
+	dec := pbsjob.NewDecoder(f)
+	var v any
+	if raw {
+		attrs := []pbsjob.JobAttr{}
+		for attr, err := range dec.Attributes() {
+			if err != nil {
+				return fmt.Errorf("decoding job file %s: %w", path, err)
+			}
+			attrs = append(attrs, attr)
+		}
+		v = attrs
+	} else {
+		job := new(pbsjob.Job)
+		if err := dec.Decode(job); err != nil {
+			return fmt.Errorf("decoding job file %s: %w", path, err)
+		}
+		v = job
+	}
+
+	b, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return fmt.Errorf("encoding json: %w", err)
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", b); err != nil {
+		return fmt.Errorf("writing json: %w", err)
+	}
+
+	return nil
+}
+
+// Writes a job file built from the job in the JSON file.
+func encodeJobFile(w io.Writer, path string) error {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading job json: %w", err)
+	}
+
+	job := new(pbsjob.Job)
+	if err := json.Unmarshal(contents, job); err != nil {
+		return fmt.Errorf("parsing job json %s: %w", path, err)
+	}
+
+	bw := bufio.NewWriter(w)
+	if err := pbsjob.NewEncoder(bw).Encode(job); err != nil {
+		return fmt.Errorf("encoding job file: %w", err)
+	}
+
+	return bw.Flush()
+}
+
+func runJobFile(command string) error {
+	switch command {
+	case jobfileDecodeCommand.FullCommand():
+		return decodeJobFile(os.Stdout, *jobfileDecodePath, *jobfileDecodeRaw)
+
+	case jobfileEncodeCommand.FullCommand():
+		if *jobfileEncodeOutput == "" {
+			return encodeJobFile(os.Stdout, *jobfileEncodePath)
+		}
+
+		f, err := os.OpenFile(*jobfileEncodeOutput, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+		if err != nil {
+			return fmt.Errorf("creating job file: %w", err)
+		}
+		if err := encodeJobFile(f, *jobfileEncodePath); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+
+	default:
+		return fmt.Errorf("unknown command %s", command)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/0nebody/pbs_exporter/internal/pbsjob"
+)
+
+const (
+	testJobFile = "../../internal/pbsjob/testdata/job_1000.bin"
+	testJobJson = "../../internal/pbsjob/testdata/job_1000.json"
+)
+
+func TestDecodeJobFile(t *testing.T) {
+	contents, err := os.ReadFile(testJobJson)
+	if err != nil {
+		t.Fatalf("Failed to read job json: %v", err)
+	}
+	want := new(pbsjob.Job)
+	if err := json.Unmarshal(contents, want); err != nil {
+		t.Fatalf("Failed to parse job json: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := decodeJobFile(&buf, testJobFile, false); err != nil {
+		t.Fatalf("decodeJobFile(%s, false) returned error: %v", testJobFile, err)
+	}
+	got := new(pbsjob.Job)
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatalf("Failed to parse decoded job: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("decodeJobFile(%s, false) = %+v, want %+v", testJobFile, got, want)
+	}
+
+	t.Run("Raw", func(tt *testing.T) {
+		var buf bytes.Buffer
+		if err := decodeJobFile(&buf, testJobFile, true); err != nil {
+			tt.Fatalf("decodeJobFile(%s, true) returned error: %v", testJobFile, err)
+		}
+		var attrs []pbsjob.JobAttr
+		if err := json.Unmarshal(buf.Bytes(), &attrs); err != nil {
+			tt.Fatalf("Failed to parse decoded attributes: %v", err)
+		}
+		wantAttr := pbsjob.JobAttr{Name: "Job_Name", Value: want.JobName}
+		if len(attrs) == 0 || attrs[0] != wantAttr {
+			tt.Errorf("decodeJobFile(%s, true) = %+v, want first %+v", testJobFile, attrs, wantAttr)
+		}
+	})
+
+	t.Run("Invalid", func(tt *testing.T) {
+		if err := decodeJobFile(&bytes.Buffer{}, testJobJson, false); err == nil {
+			tt.Errorf("decodeJobFile(%s, false) returned no error", testJobJson)
+		}
+	})
+}
+
+func TestEncodeJobFile(t *testing.T) {
+	want, err := os.ReadFile(testJobFile)
+	if err != nil {
+		t.Fatalf("Failed to read job file: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeJobFile(&buf, testJobJson); err != nil {
+		t.Fatalf("encodeJobFile(%s) returned error: %v", testJobJson, err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("encodeJobFile(%s) = %d bytes, want %d", testJobJson, buf.Len(), len(want))
+	}
+
+	t.Run("Invalid", func(tt *testing.T) {
+		if err := encodeJobFile(&bytes.Buffer{}, testJobFile); err == nil {
+			tt.Errorf("encodeJobFile(%s) returned no error", testJobFile)
+		}
+	})
+}
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
 	"github.com/0nebody/pbs_exporter/internal/collector"
 	"github.com/0nebody/pbs_exporter/internal/utils"
 	"github.com/alecthomas/kingpin/v2"
@@ -18,14 +20,49 @@ import (
 
 var (
 	cgroupCollectorEnabled = kingpin.Flag("cgroup.enabled", "Enable cgroup collector.").Default("true").Bool()
+	cgroupGpus             = kingpin.Flag("cgroup.gpus", "Export NVIDIA GPUs assigned to each job.").Default("false").Bool()
+	cgroupGracePeriod      = kingpin.Flag("cgroup.grace-period", "Period of exporting final counters of ended jobs after the job cgroup is removed, 0 to disable.").Default("60s").Duration()
+	cgroupHookConfig       = kingpin.Flag("cgroup.hook-config", "Detect the job cgroup path from the pbs_cgroups hook config in PBS home.").Default("false").Bool()
+	cgroupJobIdRegex       = kingpin.Flag("cgroup.jobid-regex", "Regex capturing job ID and array index from job cgroup paths, derived from the cgroup path when unset.").Default("").String()
+	cgroupLifecycle        = kingpin.Flag("cgroup.lifecycle", "Watch job cgroups for creation, removal and populated changes.").Default("false").Bool()
+	cgroupNestedDepth      = kingpin.Flag("cgroup.nested.depth", "Depth of nested cgroups below each job cgroup to export, 0 to disable.").Default("0").Int()
+	cgroupPath             = kingpin.Flag("cgroup.path", "Path of job cgroups relative to the cgroup root, defaults to the pbs_cgroups hook layout.").Default("").String()
+	cgroupProcessesFds     = kingpin.Flag("cgroup.processes.fds", "Export open file descriptor counts by type per job.").Default("false").Bool()
+	cgroupProcessesStates  = kingpin.Flag("cgroup.processes.states", "Export process and thread counts by scheduler state per job.").Default("false").Bool()
+	cgroupProcessesTop     = kingpin.Flag("cgroup.processes.top", "Number of top processes by CPU and RSS to export per job, 0 to disable.").Default("0").Int()
+	cgroupPssInterval      = kingpin.Flag("cgroup.pss.interval", "Minimum interval between reading job PSS from smaps_rollup, 0 to disable.").Default("0s").Duration()
 	cgroupRoot             = kingpin.Flag("cgroup.root", "Root path of cgroup filesystem hierarchy.").Default("/sys/fs/cgroup").String()
+	cgroupSlices           = kingpin.Flag("cgroup.slices", "Export usage of top-level cgroups and usage outside of PBS jobs.").Default("false").Bool()
 	jobCollectorEnabled    = kingpin.Flag("job.enabled", "Enable job collector.").Default("true").Bool()
+	jobPollInterval        = kingpin.Flag("job.poll-interval", "Interval of polling job files while the job watcher is down, 0 to disable.").Default("15s").Duration()
 	listenAddress          = kingpin.Flag("web.listen-address", "Address to listen on for web interface and telemetry.").Default(":9307").String()
 	nodeCollectorEnabled   = kingpin.Flag("node.enabled", "Enable node collector.").Default("false").Bool()
 	pbsHome                = kingpin.Flag("job.pbs_home", "PBS home directory.").Default("/var/spool/pbs").String()
+	procRoot               = kingpin.Flag("proc.root", "Root path of proc filesystem.").Default("/proc").String()
+	rogueCollectorEnabled  = kingpin.Flag("rogue.enabled", "Enable collector for user processes outside of job cgroups.").Default("false").Bool()
+	rogueMinUid            = kingpin.Flag("rogue.min-uid", "Minimum user ID of processes checked by the rogue process collector.").Default("1000").Uint64()
 	scrapeTimeout          = kingpin.Flag("scrape.timeout", "Per-scrape timeout in seconds.").Default("5").Int()
 )
 
+var (
+	serveCommand = kingpin.Command("serve", "Serve metrics (default).").Default()
+
+	jobfileCommand       = kingpin.Command("jobfile", "Inspect and build PBS job files.")
+	jobfileDecodeCommand = jobfileCommand.Command("decode", "Print a job file as JSON.")
+	jobfileDecodePath    = jobfileDecodeCommand.Arg("file", "Job file (.JB) to decode.").Required().ExistingFile()
+	jobfileDecodeRaw     = jobfileDecodeCommand.Flag("raw", "List all attributes in the job file, including those unknown to the exporter.").Default("false").Bool()
+	jobfileEncodeCommand = jobfileCommand.Command("encode", "Build a job file from JSON.")
+	jobfileEncodePath    = jobfileEncodeCommand.Arg("file", "Job JSON file to encode.").Required().ExistingFile()
+	jobfileEncodeOutput  = jobfileEncodeCommand.Flag("output", "Job file to write, defaults to stdout.").Short('o').Default("").String()
+
+	simulateCommand  = kingpin.Command("simulate", "Generate a simulated node of PBS job files and job cgroups.")
+	simulateChurn    = simulateCommand.Flag("churn", "Fraction of jobs ended and replaced each interval.").Default("0.1").Float64()
+	simulateInterval = simulateCommand.Flag("interval", "Interval between simulated usage updates and job churn, 0 to only generate jobs.").Default("0s").Duration()
+	simulateJobs     = simulateCommand.Flag("jobs", "Number of simulated jobs.").Default("100").Int()
+	simulateOut      = simulateCommand.Flag("out", "Directory to write the simulated PBS home and cgroup root to.").Required().String()
+	simulateSeed     = simulateCommand.Flag("seed", "Seed of the simulated jobs and usage.").Default("1").Uint64()
+)
+
 func redirectToMetrics(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/metrics", http.StatusFound)
 }
@@ -50,18 +87,60 @@ func main() {
 	kingpin.CommandLine.UsageWriter(os.Stdout)
 	flag.AddFlags(kingpin.CommandLine, promslogConfig)
 	kingpin.Version(version.Print("pbs_exporter"))
-	kingpin.Parse()
+	command := kingpin.Parse()
 
 	logger := promslog.New(promslogConfig)
+
+	var err error
+	switch command {
+	case jobfileDecodeCommand.FullCommand(), jobfileEncodeCommand.FullCommand():
+		err = runJobFile(command)
+	case simulateCommand.FullCommand():
+		err = runSimulate(logger)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pbs_exporter: error: %v\n", err)
+		os.Exit(1)
+	}
+	if command != serveCommand.FullCommand() {
+		return
+	}
+
 	logger.Info("Starting PBS Exporter")
 
 	// Initialize collector configuration
 	collectorConfig := collector.NewCollectorConfig(*cgroupRoot, logger)
+	collectorConfig.GracePeriod = *cgroupGracePeriod
+	collectorConfig.NestedDepth = *cgroupNestedDepth
 	collectorConfig.PbsHome = *pbsHome
+	collectorConfig.ProcRoot = *procRoot
+	collectorConfig.ProcessTopN = *cgroupProcessesTop
+	collectorConfig.PssInterval = *cgroupPssInterval
 	collectorConfig.ScrapeTimeout = *scrapeTimeout
 	collectorConfig.EnableCgroupCollector = *cgroupCollectorEnabled
+	collectorConfig.EnableFileDescriptors = *cgroupProcessesFds
+	collectorConfig.EnableGpuInfo = *cgroupGpus
 	collectorConfig.EnableJobCollector = *jobCollectorEnabled
+	collectorConfig.EnableLifecycle = *cgroupLifecycle
 	collectorConfig.EnableNodeCollector = *nodeCollectorEnabled
+	collectorConfig.EnableProcessStates = *cgroupProcessesStates
+	collectorConfig.EnableRogueCollector = *rogueCollectorEnabled
+	collectorConfig.EnableSliceMetrics = *cgroupSlices
+	collectorConfig.RogueMinUid = *rogueMinUid
+
+	jobCgroupPath := *cgroupPath
+	if *cgroupHookConfig && jobCgroupPath == "" {
+		hookConfig, err := cgroups.ReadHookConfig(*pbsHome)
+		if err != nil {
+			logger.Error("Failed to read PBS cgroup hook config, using default cgroup path", "error", err)
+		} else {
+			jobCgroupPath = hookConfig.CgroupPath(collectorConfig.CgroupVersion)
+		}
+	}
+	if err := collectorConfig.SetCgroupLayout(jobCgroupPath, *cgroupJobIdRegex); err != nil {
+		logger.Error("Invalid cgroup layout", "error", err)
+		os.Exit(1)
+	}
 	logger.Info("Using cgroup", "version", collectorConfig.CgroupVersion, "path", filepath.Join(collectorConfig.CgroupRoot, collectorConfig.CgroupPath))
 
 	// ensure required directories exist
@@ -73,21 +152,36 @@ func main() {
 		logger.Error("PBS home directory does not exist:", "path", *pbsHome)
 		os.Exit(1)
 	}
+	if *rogueCollectorEnabled && !*jobCollectorEnabled {
+		logger.Error("Rogue process collector requires the job collector")
+		os.Exit(1)
+	}
 
 	// start pbs job watcher
 	if *jobCollectorEnabled {
-		err := collector.InitialiseJobCache(*pbsHome, logger)
+		err := collector.InitialiseJobCache(*pbsHome, *jobPollInterval, logger)
 		if err != nil {
 			logger.Error("Failed to initialize job cache", "error", err)
 		}
 		go func() {
-			err := collector.WatchPbsJobs(*pbsHome, logger)
+			err := collector.WatchPbsJobs()
 			if err != nil {
 				logger.Error("Failed to watch PBS jobs", "error", err)
 			}
 		}()
 	}
 
+	// start cgroup lifecycle watcher
+	if *cgroupCollectorEnabled && *cgroupLifecycle {
+		collector.InitialiseCgroupTracker(collectorConfig)
+		go func() {
+			err := collector.WatchCgroups()
+			if err != nil {
+				logger.Error("Failed to watch cgroups", "error", err)
+			}
+		}()
+	}
+
 	// start prometheus metrics collector
 	multiCollector := collector.NewCollectors(collectorConfig)
 	prometheus.MustRegister(multiCollector)
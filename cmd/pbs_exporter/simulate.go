@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/0nebody/pbs_exporter/internal/simulate"
+	"github.com/0nebody/pbs_exporter/internal/utils"
+)
+
+func runSimulate(logger *slog.Logger) error {
+	if *simulateJobs < 0 || *simulateChurn < 0 || *simulateChurn > 1 {
+		return fmt.Errorf("simulated jobs must be positive and churn between 0 and 1")
+	}
+
+	// the exporter detects the cgroup version from the host
+	cgroupRoot := filepath.Join(*simulateOut, "cgroup")
+	pbsHome := filepath.Join(*simulateOut, "pbs_home")
+	simulator := simulate.New(simulate.Config{
+		CgroupRoot:    cgroupRoot,
+		CgroupVersion: cgroups.NewCgroupManager(cgroupRoot).Version(),
+		Churn:         *simulateChurn,
+		Hostname:      utils.MustHostname(),
+		Jobs:          *simulateJobs,
+		Logger:        logger,
+		PbsHome:       pbsHome,
+		Seed:          *simulateSeed,
+	})
+	if err := simulator.Generate(); err != nil {
+		return err
+	}
+	logger.Info("Run the exporter against the simulated node", "flags", fmt.Sprintf("--job.pbs_home=%s --cgroup.root=%s", pbsHome, cgroupRoot))
+
+	if *simulateInterval <= 0 {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return simulator.Run(ctx, *simulateInterval)
+}
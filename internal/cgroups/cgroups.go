@@ -2,8 +2,12 @@ package cgroups
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/0nebody/pbs_exporter/internal/utils"
 	"github.com/containerd/cgroups/v3"
@@ -11,9 +15,17 @@ import (
 
 var (
 	ErrCgroupUninitialised = errors.New("cgroup uninitialised")
+	sysDevBlockPath        = "/sys/dev/block"
 )
 
+type blockDevice struct {
+	major uint64
+	minor uint64
+}
+
 type CgroupManager interface {
+	Created(path string) (time.Time, error)
+	Dir(path string) string
 	List(path string) ([]string, error)
 	Load(path string) (Cgroup, error)
 	Version() string
@@ -31,13 +43,24 @@ type Metrics struct {
 	Controllers []string
 	Io          IO
 	Cpu         CPU
+	Freezer     string
+	Gpus        []int // minors of NVIDIA GPUs allowed by the device controller, nil when unknown
 	Hugetlb     []Hugetlb
 	Memory      Memory
 	Tasks       Tasks
 }
 
+// Freezer states; empty when the freezer is unavailable.
+const (
+	FreezerFreezing = "freezing"
+	FreezerFrozen   = "frozen"
+	FreezerThawed   = "thawed"
+)
+
 type CPU struct {
 	Count  int
+	Cpus   []int
+	Mems   []int
 	System uint64
 	Usage  uint64
 	User   uint64
@@ -51,11 +74,27 @@ type Hugetlb struct {
 }
 
 type IO struct {
-	Usage []IoUsage
+	Limits []IoLimit
+	Usage  []IoUsage
+}
+
+// IO limits of a block device; unlimited values are math.MaxUint64.
+type IoLimit struct {
+	Device string
+	Major  uint64
+	Minor  uint64
+	Rbps   uint64
+	Riops  uint64
+	Wbps   uint64
+	Wiops  uint64
 }
 
 type IoUsage struct {
+	Device string
 	Major  uint64
+	Minor  uint64
+	Dbytes uint64
+	Dios   uint64
 	Rbytes uint64
 	Rios   uint64
 	Wbytes uint64
@@ -89,12 +128,15 @@ type Tasks struct {
 }
 
 func NewCgroupManager(root string) CgroupManager {
-	if cgroups.Mode() == cgroups.Unified {
+	switch cgroups.Mode() {
+	case cgroups.Unified:
 		return &CgroupV2Manager{
 			version: "v2",
 			root:    root,
 		}
-	} else {
+	case cgroups.Hybrid:
+		return newCgroupHybridManager(root)
+	default:
 		return &CgroupV1Manager{
 			version: "v1",
 			root:    root,
@@ -121,17 +163,90 @@ func listCgroups(root string, path string) ([]string, error) {
 	return cgroupPaths, nil
 }
 
+// Lists cgroups below path up to depth levels, parents before children.
+func ListNested(manager CgroupManager, path string, depth int) ([]string, error) {
+	var nestedPaths []string
+
+	parents := []string{path}
+	for range depth {
+		var children []string
+		for _, parent := range parents {
+			cgroupPaths, err := manager.List(parent)
+			if err != nil {
+				// the root must exist, nested cgroups may be removed while walking
+				if parent == path {
+					return nil, err
+				}
+				continue
+			}
+			children = append(children, cgroupPaths...)
+		}
+		nestedPaths = append(nestedPaths, children...)
+		parents = children
+	}
+
+	return nestedPaths, nil
+}
+
+// The modification time of a cgroup directory is set on creation and only
+// changes when child cgroups are added or removed.
+func cgroupCreated(cgroupPath string) (time.Time, error) {
+	info, err := os.Stat(cgroupPath)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return info.ModTime(), nil
+}
+
 func GetCgroupCPUs(cgroupRoot string, cgroupPath string) ([]int, error) {
-	cpuSetPath := filepath.Join(cgroupRoot, cgroupPath, "cpuset.cpus")
-	cpuSetList, err := utils.ReadFileSingleLine(cpuSetPath)
+	return readListFormat(filepath.Join(cgroupRoot, cgroupPath, "cpuset.cpus"))
+}
+
+func GetCgroupMems(cgroupRoot string, cgroupPath string) ([]int, error) {
+	return readListFormat(filepath.Join(cgroupRoot, cgroupPath, "cpuset.mems"))
+}
+
+func readListFormat(path string) ([]int, error) {
+	list, err := utils.ReadFileSingleLine(path)
 	if err != nil {
 		return nil, err
 	}
 
-	cpuSet, err := utils.ParseListFormat(cpuSetList)
+	values, err := utils.ParseListFormat(list)
 	if err != nil {
 		return nil, err
 	}
 
-	return cpuSet, nil
+	return values, nil
+}
+
+// Resolves a block device major:minor number to its kernel device name, such
+// as nvme0n1 or dm-0. Returns an empty string when the device is unknown.
+func BlockDeviceName(major uint64, minor uint64) string {
+	devicePath := filepath.Join(sysDevBlockPath, fmt.Sprintf("%d:%d", major, minor))
+	target, err := os.Readlink(devicePath)
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Base(target)
+}
+
+func parseDeviceNumber(device string) (uint64, uint64, error) {
+	majorStr, minorStr, found := strings.Cut(device, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid device number '%s'", device)
+	}
+
+	major, err := strconv.ParseUint(majorStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing device major '%s': %w", device, err)
+	}
+	minor, err := strconv.ParseUint(minorStr, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing device minor '%s': %w", device, err)
+	}
+
+	return major, minor, nil
 }
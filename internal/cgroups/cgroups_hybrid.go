@@ -0,0 +1,201 @@
+package cgroups
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/utils"
+	"github.com/containerd/cgroups/v3/cgroup1"
+)
+
+var (
+	// v2 controllers with a differently named v1 equivalent
+	v1ControllerNames = map[string]string{
+		"io": "blkio",
+	}
+)
+
+// Hybrid hosts mount v1 controllers alongside a v2 hierarchy at unified.
+// Each controller is read from the hierarchy it is bound to.
+type CgroupHybridManager struct {
+	version string
+	root    string
+	v1      *CgroupV1Manager
+	v2      *CgroupV2Manager
+}
+
+type CgroupHybrid struct {
+	v1 *CgroupV1
+	v2 *CgroupV2
+}
+
+func newCgroupHybridManager(root string) *CgroupHybridManager {
+	return &CgroupHybridManager{
+		version: "hybrid",
+		root:    root,
+		v1: &CgroupV1Manager{
+			version: "v1",
+			root:    root,
+		},
+		v2: &CgroupV2Manager{
+			version: "v2",
+			root:    filepath.Join(root, "unified"),
+		},
+	}
+}
+
+func (m *CgroupHybridManager) Created(path string) (time.Time, error) {
+	created, err := m.v1.Created(path)
+	if err != nil {
+		return m.v2.Created(path)
+	}
+
+	return created, nil
+}
+
+func (m *CgroupHybridManager) Dir(path string) string {
+	if dir := m.v1.Dir(path); utils.DirectoryExists(dir) {
+		return dir
+	}
+
+	return m.v2.Dir(path)
+}
+
+func (m *CgroupHybridManager) List(path string) ([]string, error) {
+	cgroupPaths, err := m.v1.List(path)
+	if err != nil {
+		return m.v2.List(path)
+	}
+
+	return cgroupPaths, nil
+}
+
+func (m *CgroupHybridManager) Load(path string) (Cgroup, error) {
+	cgroup := &CgroupHybrid{}
+
+	v1Cgroup, err := m.v1.Load(path)
+	if err != nil && !errors.Is(err, cgroup1.ErrCgroupDeleted) {
+		return nil, err
+	}
+	if err == nil {
+		cgroup.v1 = v1Cgroup.(*CgroupV1)
+	}
+
+	v2Cgroup, err := m.v2.Load(path)
+	if err == nil {
+		cgroup.v2 = v2Cgroup.(*CgroupV2)
+	}
+
+	if cgroup.v1 == nil && cgroup.v2 == nil {
+		return nil, cgroup1.ErrCgroupDeleted
+	}
+
+	return cgroup, nil
+}
+
+func (c *CgroupHybridManager) Version() string {
+	return c.version
+}
+
+// Controllers of the v2 cgroup which are not bound to a v1 hierarchy.
+func (c *CgroupHybrid) v2Controllers() []string {
+	if c.v2 == nil {
+		return nil
+	}
+
+	var controllers []string
+	for _, controller := range c.v2.controllers {
+		v1Name, ok := v1ControllerNames[controller]
+		if !ok {
+			v1Name = controller
+		}
+		if c.v1 != nil && slices.Contains(c.v1.subsystems, v1Name) {
+			continue
+		}
+		controllers = append(controllers, controller)
+	}
+
+	return controllers
+}
+
+func (c *CgroupHybrid) Stat() (*Metrics, error) {
+	if c.v1 == nil {
+		return c.v2.Stat()
+	}
+
+	metrics, err := c.v1.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if metrics.Freezer == "" && c.v2 != nil {
+		freezer, err := readFreezer(filepath.Join(c.v2.root, c.v2.path))
+		if err != nil {
+			return nil, err
+		}
+		metrics.Freezer = freezer
+	}
+
+	v2Controllers := c.v2Controllers()
+	if len(v2Controllers) == 0 {
+		return metrics, nil
+	}
+
+	v2Metrics, err := c.v2.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	metrics.Controllers = slices.Clone(metrics.Controllers)
+	for _, controller := range v2Controllers {
+		switch controller {
+		case "cpu":
+			metrics.Cpu.System = v2Metrics.Cpu.System
+			metrics.Cpu.Usage = v2Metrics.Cpu.Usage
+			metrics.Cpu.User = v2Metrics.Cpu.User
+		case "cpuset":
+			metrics.Cpu.Count = v2Metrics.Cpu.Count
+			metrics.Cpu.Cpus = v2Metrics.Cpu.Cpus
+			metrics.Cpu.Mems = v2Metrics.Cpu.Mems
+		case "hugetlb":
+			metrics.Hugetlb = v2Metrics.Hugetlb
+		case "io":
+			metrics.Io = v2Metrics.Io
+		case "memory":
+			metrics.Memory = v2Metrics.Memory
+		case "pids":
+			metrics.Tasks.PidLimit = v2Metrics.Tasks.PidLimit
+		default:
+			continue
+		}
+		metrics.Controllers = append(metrics.Controllers, controller)
+	}
+
+	return metrics, nil
+}
+
+func (c *CgroupHybrid) Procs() ([]uint64, error) {
+	if c.v1 == nil {
+		return c.v2.Procs()
+	}
+
+	return c.v1.Procs()
+}
+
+func (c *CgroupHybrid) Threads() ([]uint64, error) {
+	if c.v1 == nil {
+		return c.v2.Threads()
+	}
+
+	return c.v1.Threads()
+}
+
+func (c *CgroupHybrid) CpuCount() (int, error) {
+	if c.v1 == nil || slices.Contains(c.v2Controllers(), "cpuset") {
+		return c.v2.CpuCount()
+	}
+
+	return c.v1.CpuCount()
+}
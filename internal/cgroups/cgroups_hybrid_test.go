@@ -0,0 +1,78 @@
+package cgroups
+
+import (
+	"slices"
+	"testing"
+
+	v1 "github.com/containerd/cgroups/v3/cgroup1/stats"
+	v2 "github.com/containerd/cgroups/v3/cgroup2/stats"
+)
+
+func TestStatHybrid(t *testing.T) {
+	cgroup := &CgroupHybrid{
+		v1: &CgroupV1{
+			cgroup: &MockCgroupV1{
+				MockProcsVal: []uint64{1234},
+				MockStatVal: &v1.Metrics{
+					CPU: &v1.CPUStat{
+						Usage: &v1.CPUUsage{Total: 3000000000},
+					},
+				},
+			},
+			subsystems: []string{"cpu", "cpuacct"},
+		},
+		v2: &CgroupV2{
+			cgroup: &MockCgroupV2{
+				MockStatVal: &v2.Metrics{
+					CPU:    &v2.CPUStat{UsageUsec: 9000000},
+					Memory: &v2.MemoryStat{Usage: 2048, UsageLimit: 4096},
+				},
+			},
+			controllers: []string{"cpu", "memory"},
+		},
+	}
+
+	got, err := cgroup.Stat()
+	if err != nil {
+		t.Fatalf("Stat() returned error: %v", err)
+	}
+	if got.Cpu.Usage != 3 {
+		t.Errorf("Stat() Cpu.Usage = %d, want %d from v1", got.Cpu.Usage, 3)
+	}
+	if got.Memory.Usage != 2048 || got.Memory.Limit != 4096 {
+		t.Errorf("Stat() Memory = %+v, want usage 2048 and limit 4096 from v2", got.Memory)
+	}
+	wantControllers := []string{"cpu", "cpuacct", "memory"}
+	if !slices.Equal(got.Controllers, wantControllers) {
+		t.Errorf("Stat() Controllers = %v, want %v", got.Controllers, wantControllers)
+	}
+	if !slices.Equal(got.Tasks.Pids, []uint64{1234}) {
+		t.Errorf("Stat() Tasks.Pids = %v, want %v", got.Tasks.Pids, []uint64{1234})
+	}
+}
+
+func TestV2ControllersHybrid(t *testing.T) {
+	tests := []struct {
+		name       string
+		subsystems []string
+		v2         []string
+		want       []string
+	}{
+		{"No v2 controllers", []string{"cpu", "memory"}, nil, nil},
+		{"Bound to v1", []string{"blkio", "cpu", "memory"}, []string{"cpu", "io"}, nil},
+		{"Bound to v2", []string{"cpu"}, []string{"io", "memory"}, []string{"io", "memory"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cgroup := &CgroupHybrid{
+				v1: &CgroupV1{subsystems: tt.subsystems},
+				v2: &CgroupV2{controllers: tt.v2},
+			}
+			got := cgroup.v2Controllers()
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("v2Controllers() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"slices"
 	"testing"
+	"time"
 )
 
 var testMetric = &Metrics{
@@ -26,7 +27,9 @@ var testMetric = &Metrics{
 	Io: IO{
 		Usage: []IoUsage{
 			{
+				Device: "dm-0",
 				Major:  253,
+				Minor:  0,
 				Rbytes: 1000,
 				Rios:   100,
 				Wbytes: 2000,
@@ -60,6 +63,19 @@ var testMetric = &Metrics{
 	},
 }
 
+// Mocks /sys/dev/block with device 253:0 linked to dm-0.
+func mockSysDevBlock(t *testing.T) {
+	t.Helper()
+	devBlockPath := t.TempDir()
+	if err := os.Symlink("../../devices/virtual/block/dm-0", filepath.Join(devBlockPath, "253:0")); err != nil {
+		t.Fatalf("Failed to create device symlink: %v", err)
+	}
+
+	original := sysDevBlockPath
+	sysDevBlockPath = devBlockPath
+	t.Cleanup(func() { sysDevBlockPath = original })
+}
+
 func TestNewCgroupManager(t *testing.T) {
 	manager := NewCgroupManager("")
 
@@ -73,6 +89,29 @@ func TestNewCgroupManager(t *testing.T) {
 	}
 }
 
+func TestCgroupCreated(t *testing.T) {
+	cgroupPath := filepath.Join(t.TempDir(), "1000.pbs")
+	if err := os.Mkdir(cgroupPath, 0755); err != nil {
+		t.Fatalf("Failed to create cgroup directory: %v", err)
+	}
+	want := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(cgroupPath, want, want); err != nil {
+		t.Fatalf("Failed to set cgroup directory times: %v", err)
+	}
+
+	got, err := cgroupCreated(cgroupPath)
+	if err != nil {
+		t.Fatalf("cgroupCreated(%s) returned error: %v", cgroupPath, err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("cgroupCreated(%s) = %v, want %v", cgroupPath, got, want)
+	}
+
+	if _, err := cgroupCreated(filepath.Join(cgroupPath, "missing")); err == nil {
+		t.Errorf("cgroupCreated() expected error for missing cgroup")
+	}
+}
+
 func TestListCgroups(t *testing.T) {
 	cgroupRoot := t.TempDir()
 	cgroupPath := "testcgroup"
@@ -142,6 +181,39 @@ func TestListCgroups(t *testing.T) {
 	})
 }
 
+func TestListNested(t *testing.T) {
+	cgroupRoot := t.TempDir()
+	for _, dir := range []string{"jobs/1000.pbs/rank_0/task", "jobs/1000.pbs/rank_1"} {
+		if err := os.MkdirAll(filepath.Join(cgroupRoot, dir), 0755); err != nil {
+			t.Fatalf("Failed to create cgroup directory: %v", err)
+		}
+	}
+	manager := &CgroupV2Manager{root: cgroupRoot}
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, nil},
+		{1, []string{"/jobs/1000.pbs/rank_0", "/jobs/1000.pbs/rank_1"}},
+		{3, []string{"/jobs/1000.pbs/rank_0", "/jobs/1000.pbs/rank_1", "/jobs/1000.pbs/rank_0/task"}},
+	}
+
+	for _, tt := range tests {
+		got, err := ListNested(manager, "/jobs/1000.pbs", tt.depth)
+		if err != nil {
+			t.Fatalf("ListNested(%d) returned error: %v", tt.depth, err)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("ListNested(%d) = %v, want %v", tt.depth, got, tt.want)
+		}
+	}
+
+	if _, err := ListNested(manager, "/jobs/1001.pbs", 1); err == nil {
+		t.Errorf("ListNested() expected error for missing cgroup")
+	}
+}
+
 func TestGetCgroupCPUs(t *testing.T) {
 	cgroupFs := t.TempDir()
 	tests := []struct {
@@ -186,3 +258,88 @@ func TestGetCgroupCPUs(t *testing.T) {
 		})
 	}
 }
+
+func TestGetCgroupMems(t *testing.T) {
+	cgroupFs := t.TempDir()
+	tests := []struct {
+		name string
+		mems string
+		want []int
+	}{
+		{
+			name: "Success single node",
+			mems: "0\n",
+			want: []int{0},
+		},
+		{
+			name: "Success range",
+			mems: "0-1\n",
+			want: []int{0, 1},
+		},
+		{
+			name: "failure empty mems",
+			mems: "\n",
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile(filepath.Join(cgroupFs, "cpuset.mems"), []byte(tt.mems), 0644); err != nil {
+				t.Fatalf("Failed to write mems file: %v", err)
+			}
+			mems, err := GetCgroupMems(cgroupFs, "")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !slices.Equal(mems, tt.want) {
+				t.Errorf("GetCgroupMems(%v, '') %v, want %v", cgroupFs, mems, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockDeviceName(t *testing.T) {
+	mockSysDevBlock(t)
+
+	tests := []struct {
+		major uint64
+		minor uint64
+		want  string
+	}{
+		{253, 0, "dm-0"},
+		{253, 1, ""},
+	}
+
+	for _, test := range tests {
+		got := BlockDeviceName(test.major, test.minor)
+		if got != test.want {
+			t.Errorf("BlockDeviceName(%d, %d) = %v, want %v", test.major, test.minor, got, test.want)
+		}
+	}
+}
+
+func TestParseDeviceNumber(t *testing.T) {
+	tests := []struct {
+		device    string
+		wantMajor uint64
+		wantMinor uint64
+		wantErr   bool
+	}{
+		{"253:0", 253, 0, false},
+		{"259:12", 259, 12, false},
+		{"253", 0, 0, true},
+		{"a:0", 0, 0, true},
+		{"253:b", 0, 0, true},
+	}
+
+	for _, test := range tests {
+		major, minor, err := parseDeviceNumber(test.device)
+		if (err != nil) != test.wantErr {
+			t.Fatalf("parseDeviceNumber(%s) error = %v, wantErr %v", test.device, err, test.wantErr)
+		}
+		if major != test.wantMajor || minor != test.wantMinor {
+			t.Errorf("parseDeviceNumber(%s) = %d:%d, want %d:%d", test.device, major, minor, test.wantMajor, test.wantMinor)
+		}
+	}
+}
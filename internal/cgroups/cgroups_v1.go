@@ -1,11 +1,17 @@
 package cgroups
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/0nebody/pbs_exporter/internal/utils"
 	"github.com/containerd/cgroups/v3/cgroup1"
 	v1 "github.com/containerd/cgroups/v3/cgroup1/stats"
 )
@@ -33,6 +39,14 @@ type CgroupV1 struct {
 	cgroup     CgroupV1Api
 }
 
+func (m *CgroupV1Manager) Created(path string) (time.Time, error) {
+	return cgroupCreated(m.Dir(path))
+}
+
+func (m *CgroupV1Manager) Dir(path string) string {
+	return filepath.Join(m.root, "/cpu,cpuacct", path)
+}
+
 func (m *CgroupV1Manager) List(path string) ([]string, error) {
 	return listCgroups(filepath.Join(m.root, "/cpu,cpuacct"), path)
 }
@@ -74,37 +88,52 @@ func (c *CgroupV1) Stat() (*Metrics, error) {
 
 	if slices.Contains(metrics.Controllers, "blkio") {
 		statIO := stat.GetBlkio()
-		ioMap := make(map[uint64]*IoUsage)
-		ioMapGet := func(major uint64) *IoUsage {
-			if _, ok := ioMap[major]; !ok {
-				ioMap[major] = &IoUsage{Major: major}
+		ioMap := make(map[blockDevice]*IoUsage)
+		ioMapGet := func(major uint64, minor uint64) *IoUsage {
+			device := blockDevice{major: major, minor: minor}
+			if _, ok := ioMap[device]; !ok {
+				ioMap[device] = &IoUsage{
+					Device: BlockDeviceName(major, minor),
+					Major:  major,
+					Minor:  minor,
+				}
 			}
-			return ioMap[major]
+			return ioMap[device]
 		}
 
 		for _, ioUsage := range statIO.GetIoServiceBytesRecursive() {
-			ioStat := ioMapGet(ioUsage.GetMajor())
+			ioStat := ioMapGet(ioUsage.GetMajor(), ioUsage.GetMinor())
 			switch operation := ioUsage.GetOp(); operation {
 			case "Read":
 				ioStat.Rbytes += ioUsage.GetValue()
 			case "Write":
 				ioStat.Wbytes += ioUsage.GetValue()
+			case "Discard":
+				ioStat.Dbytes += ioUsage.GetValue()
 			}
 		}
 
 		for _, ioUsage := range statIO.GetIoServicedRecursive() {
-			ioStat := ioMapGet(ioUsage.GetMajor())
+			ioStat := ioMapGet(ioUsage.GetMajor(), ioUsage.GetMinor())
 			switch operation := ioUsage.GetOp(); operation {
 			case "Read":
 				ioStat.Rios += ioUsage.GetValue()
 			case "Write":
 				ioStat.Wios += ioUsage.GetValue()
+			case "Discard":
+				ioStat.Dios += ioUsage.GetValue()
 			}
 		}
 
 		for _, ioStat := range ioMap {
 			metrics.Io.Usage = append(metrics.Io.Usage, *ioStat)
 		}
+
+		ioLimits, err := c.IoLimits()
+		if err != nil {
+			return nil, err
+		}
+		metrics.Io.Limits = ioLimits
 	}
 
 	if slices.Contains(metrics.Controllers, "cpu") {
@@ -116,11 +145,34 @@ func (c *CgroupV1) Stat() (*Metrics, error) {
 	}
 
 	if slices.Contains(metrics.Controllers, "cpuset") {
-		cpuCount, err := c.CpuCount()
+		cpus, err := GetCgroupCPUs(filepath.Join(c.root, "cpuset"), c.path)
+		if err != nil {
+			return nil, err
+		}
+		mems, err := GetCgroupMems(filepath.Join(c.root, "cpuset"), c.path)
+		if err != nil {
+			return nil, err
+		}
+		metrics.Cpu.Count = len(cpus)
+		metrics.Cpu.Cpus = cpus
+		metrics.Cpu.Mems = mems
+	}
+
+	if slices.Contains(metrics.Controllers, "devices") {
+		gpus, err := readDevicesList(filepath.Join(c.root, "devices", c.path, "devices.list"))
 		if err != nil {
 			return nil, err
 		}
-		metrics.Cpu.Count = cpuCount
+		metrics.Gpus = gpus
+	}
+
+	if slices.Contains(metrics.Controllers, "freezer") {
+		// freezer.state is absent in the root cgroup
+		state, err := utils.ReadFileSingleLine(filepath.Join(c.root, "freezer", c.path, "freezer.state"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		metrics.Freezer = strings.ToLower(state)
 	}
 
 	if slices.Contains(metrics.Controllers, "hugetlb") {
@@ -237,6 +289,67 @@ func (c *CgroupV1) CpuCount() (int, error) {
 	return len(cgroupCPUs), nil
 }
 
+// Reads blkio throttle limits, devices without a limit are not listed.
+func (c *CgroupV1) IoLimits() ([]IoLimit, error) {
+	limitMap := make(map[blockDevice]*IoLimit)
+	throttleFiles := []struct {
+		name  string
+		field func(*IoLimit) *uint64
+	}{
+		{"blkio.throttle.read_bps_device", func(l *IoLimit) *uint64 { return &l.Rbps }},
+		{"blkio.throttle.read_iops_device", func(l *IoLimit) *uint64 { return &l.Riops }},
+		{"blkio.throttle.write_bps_device", func(l *IoLimit) *uint64 { return &l.Wbps }},
+		{"blkio.throttle.write_iops_device", func(l *IoLimit) *uint64 { return &l.Wiops }},
+	}
+
+	for _, throttleFile := range throttleFiles {
+		throttlePath := filepath.Join(c.root, "blkio", c.path, throttleFile.name)
+		content, err := os.ReadFile(throttlePath)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+
+		for line := range strings.Lines(string(content)) {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			major, minor, err := parseDeviceNumber(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %w", throttleFile.name, err)
+			}
+
+			device := blockDevice{major: major, minor: minor}
+			if _, ok := limitMap[device]; !ok {
+				limitMap[device] = &IoLimit{
+					Device: BlockDeviceName(major, minor),
+					Major:  major,
+					Minor:  minor,
+					Rbps:   math.MaxUint64,
+					Riops:  math.MaxUint64,
+					Wbps:   math.MaxUint64,
+					Wiops:  math.MaxUint64,
+				}
+			}
+			*throttleFile.field(limitMap[device]) = value
+		}
+	}
+
+	var ioLimits []IoLimit
+	for _, limit := range limitMap {
+		ioLimits = append(ioLimits, *limit)
+	}
+
+	return ioLimits, nil
+}
+
 func cgroupsV1Hierarchy(root string) cgroup1.Hierarchy {
 	return func() ([]cgroup1.Subsystem, error) {
 		h, err := cgroup1.NewHugetlb(root)
@@ -251,6 +364,7 @@ func cgroupsV1Hierarchy(root string) cgroup1.Hierarchy {
 			cgroup1.NewNetPrio(root),
 			cgroup1.NewPerfEvent(root),
 			cgroup1.NewCpuset(root),
+			cgroup1.NewDevices(root),
 			cgroup1.NewCpu(root),
 			cgroup1.NewCpuacct(root),
 			cgroup1.NewMemory(root),
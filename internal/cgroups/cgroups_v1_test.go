@@ -1,6 +1,7 @@
 package cgroups
 
 import (
+	"math"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -80,6 +81,7 @@ func TestLoadV1(t *testing.T) {
 }
 
 func TestStatV1(t *testing.T) {
+	mockSysDevBlock(t)
 	MockCgroupV1 := &MockCgroupV1{
 		MockStatVal: &v1.Metrics{
 			Blkio: &v1.BlkIOStat{
@@ -174,6 +176,44 @@ func TestStatV1(t *testing.T) {
 	}
 }
 
+func TestStatV1Freezer(t *testing.T) {
+	cgroupFs := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(cgroupFs, "freezer", "jobs", "1000.pbs"), 0755); err != nil {
+		t.Fatalf("Failed to create freezer hierarchy: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(cgroupFs, "freezer", "jobs", "1000.pbs", "freezer.state"), []byte("FROZEN\n"), 0644); err != nil {
+		t.Fatalf("Failed to write freezer.state: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"Job cgroup", "/jobs/1000.pbs", FreezerFrozen},
+		// the root cgroup has no freezer.state
+		{"Root cgroup", "/", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cgroup := &CgroupV1{
+				root:       cgroupFs,
+				path:       tt.path,
+				cgroup:     &MockCgroupV1{MockStatVal: &v1.Metrics{}},
+				subsystems: []string{"freezer"},
+			}
+			got, err := cgroup.Stat()
+			if err != nil {
+				t.Fatalf("Stat() returned error: %v", err)
+			}
+			if got.Freezer != tt.want {
+				t.Errorf("Stat() freezer = %q, want %q", got.Freezer, tt.want)
+			}
+		})
+	}
+}
+
 func TestProcsV1(t *testing.T) {
 	want := []uint64{1234, 5678}
 	MockCgroupV1 := &MockCgroupV1{
@@ -235,6 +275,39 @@ func TestCpuCountV1(t *testing.T) {
 	}
 }
 
+func TestIoLimitsV1(t *testing.T) {
+	mockSysDevBlock(t)
+	cgroupFs := t.TempDir()
+	blkioPath := filepath.Join(cgroupFs, "blkio")
+	if err := os.MkdirAll(blkioPath, 0755); err != nil {
+		t.Fatalf("Failed to create blkio directory: %v", err)
+	}
+	throttle := map[string]string{
+		"blkio.throttle.read_bps_device":   "253:0 1048576\n",
+		"blkio.throttle.write_iops_device": "253:0 120\n",
+	}
+	for name, content := range throttle {
+		if err := os.WriteFile(filepath.Join(blkioPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	want := []IoLimit{
+		{Device: "dm-0", Major: 253, Minor: 0, Rbps: 1048576, Riops: math.MaxUint64, Wbps: math.MaxUint64, Wiops: 120},
+	}
+
+	cgroupV1 := &CgroupV1{
+		root: cgroupFs,
+		path: "",
+	}
+	got, err := cgroupV1.IoLimits()
+	if err != nil {
+		t.Fatalf("IoLimits() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("IoLimits() = %+v, want %+v", got, want)
+	}
+}
+
 func TestCgroupsV1Hierarchy(t *testing.T) {
 	cgroupFs := t.TempDir()
 	hierarchy, err := cgroupsV1Hierarchy(cgroupFs)()
@@ -245,7 +318,7 @@ func TestCgroupsV1Hierarchy(t *testing.T) {
 		t.Fatal("Expected non-empty hierarchy")
 	}
 	got := []string{}
-	want := []string{"cpu", "cpuacct", "cpuset", "memory", "pids", "hugetlb", "blkio", "systemd", "freezer", "net_cls", "net_prio", "perf_event", "rdma"}
+	want := []string{"cpu", "cpuacct", "cpuset", "devices", "memory", "pids", "hugetlb", "blkio", "systemd", "freezer", "net_cls", "net_prio", "perf_event", "rdma"}
 
 	for _, subsystem := range hierarchy {
 		got = append(got, string(subsystem.Name()))
@@ -1,8 +1,18 @@
 package cgroups
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/0nebody/pbs_exporter/internal/utils"
 	"github.com/containerd/cgroups/v3/cgroup2"
 	v2 "github.com/containerd/cgroups/v3/cgroup2/stats"
 )
@@ -30,6 +40,14 @@ type CgroupV2 struct {
 	cgroup      CgroupV2Api
 }
 
+func (m *CgroupV2Manager) Created(path string) (time.Time, error) {
+	return cgroupCreated(m.Dir(path))
+}
+
+func (m *CgroupV2Manager) Dir(path string) string {
+	return filepath.Join(m.root, path)
+}
+
 func (m *CgroupV2Manager) List(path string) ([]string, error) {
 	return listCgroups(m.root, path)
 }
@@ -77,12 +95,24 @@ func (c *CgroupV2) Stat() (*Metrics, error) {
 	}
 
 	if slices.Contains(metrics.Controllers, "cpuset") {
-		cpuCount, err := c.CpuCount()
+		cpus, err := GetCgroupCPUs(c.root, c.path)
 		if err != nil {
 			return nil, err
 		}
-		metrics.Cpu.Count = cpuCount
+		mems, err := GetCgroupMems(c.root, c.path)
+		if err != nil {
+			return nil, err
+		}
+		metrics.Cpu.Count = len(cpus)
+		metrics.Cpu.Cpus = cpus
+		metrics.Cpu.Mems = mems
+	}
+
+	freezer, err := readFreezer(filepath.Join(c.root, c.path))
+	if err != nil {
+		return nil, err
 	}
+	metrics.Freezer = freezer
 
 	if slices.Contains(metrics.Controllers, "hugetlb") {
 		statHugetlb := stat.GetHugetlb()
@@ -96,18 +126,19 @@ func (c *CgroupV2) Stat() (*Metrics, error) {
 		}
 	}
 
+	// containerd omits discard stats and io.max, read the files directly
 	if slices.Contains(metrics.Controllers, "io") {
-		statIO := stat.GetIo()
-		statIoUsage := statIO.GetUsage()
-		for _, ioUsage := range statIoUsage {
-			metrics.Io.Usage = append(metrics.Io.Usage, IoUsage{
-				Major:  ioUsage.GetMajor(),
-				Rbytes: ioUsage.GetRbytes(),
-				Rios:   ioUsage.GetRios(),
-				Wbytes: ioUsage.GetWbytes(),
-				Wios:   ioUsage.GetWios(),
-			})
+		ioUsage, err := readIoStat(filepath.Join(c.root, c.path, "io.stat"))
+		if err != nil {
+			return nil, err
+		}
+		metrics.Io.Usage = ioUsage
+
+		ioLimits, err := readIoMax(filepath.Join(c.root, c.path, "io.max"))
+		if err != nil {
+			return nil, err
 		}
+		metrics.Io.Limits = ioLimits
 	}
 
 	if slices.Contains(metrics.Controllers, "memory") {
@@ -182,3 +213,164 @@ func (c *CgroupV2) CpuCount() (int, error) {
 
 	return len(cgroupCPUs), nil
 }
+
+// The freezer is part of the v2 core; cgroup.freeze is the requested state
+// and the frozen key of cgroup.events whether it has been reached. Both are
+// absent in the root cgroup.
+func readFreezer(cgroupPath string) (string, error) {
+	freeze, err := utils.ReadFileSingleLine(filepath.Join(cgroupPath, "cgroup.freeze"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	events, err := readFlatKeyed(filepath.Join(cgroupPath, "cgroup.events"))
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case events["frozen"] == "1":
+		return FreezerFrozen, nil
+	case freeze == "1":
+		return FreezerFreezing, nil
+	default:
+		return FreezerThawed, nil
+	}
+}
+
+// Reads a flat keyed file such as cgroup.events.
+func readFlatKeyed(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if key, value, found := strings.Cut(scanner.Text(), " "); found {
+			values[key] = value
+		}
+	}
+
+	return values, scanner.Err()
+}
+
+// Parses io.stat entries; "<major>:<minor> rbytes=1 wbytes=1 rios=1 wios=1 dbytes=0 dios=0".
+func readIoStat(path string) ([]IoUsage, error) {
+	var ioUsage []IoUsage
+
+	err := readKeyedDeviceFile(path, func(major uint64, minor uint64, values map[string]string) error {
+		usage := IoUsage{
+			Device: BlockDeviceName(major, minor),
+			Major:  major,
+			Minor:  minor,
+		}
+		fields := map[string]*uint64{
+			"dbytes": &usage.Dbytes,
+			"dios":   &usage.Dios,
+			"rbytes": &usage.Rbytes,
+			"rios":   &usage.Rios,
+			"wbytes": &usage.Wbytes,
+			"wios":   &usage.Wios,
+		}
+		for key, field := range fields {
+			value, ok := values[key]
+			if !ok {
+				continue
+			}
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parsing io.stat %s: %w", key, err)
+			}
+			*field = v
+		}
+		ioUsage = append(ioUsage, usage)
+
+		return nil
+	})
+
+	return ioUsage, err
+}
+
+// Parses io.max entries; "<major>:<minor> rbps=max wbps=max riops=max wiops=max".
+func readIoMax(path string) ([]IoLimit, error) {
+	var ioLimits []IoLimit
+
+	err := readKeyedDeviceFile(path, func(major uint64, minor uint64, values map[string]string) error {
+		limit := IoLimit{
+			Device: BlockDeviceName(major, minor),
+			Major:  major,
+			Minor:  minor,
+			Rbps:   math.MaxUint64,
+			Riops:  math.MaxUint64,
+			Wbps:   math.MaxUint64,
+			Wiops:  math.MaxUint64,
+		}
+		fields := map[string]*uint64{
+			"rbps":  &limit.Rbps,
+			"riops": &limit.Riops,
+			"wbps":  &limit.Wbps,
+			"wiops": &limit.Wiops,
+		}
+		for key, field := range fields {
+			value, ok := values[key]
+			if !ok || value == "max" {
+				continue
+			}
+			v, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("parsing io.max %s: %w", key, err)
+			}
+			*field = v
+		}
+		ioLimits = append(ioLimits, limit)
+
+		return nil
+	})
+
+	return ioLimits, err
+}
+
+// Reads a nested keyed file with one device per line. Missing files are
+// ignored as io.max is absent from the root cgroup.
+func readKeyedDeviceFile(path string, fn func(major uint64, minor uint64, values map[string]string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		major, minor, err := parseDeviceNumber(fields[0])
+		if err != nil {
+			return err
+		}
+
+		values := make(map[string]string, len(fields)-1)
+		for _, field := range fields[1:] {
+			if key, value, found := strings.Cut(field, "="); found {
+				values[key] = value
+			}
+		}
+
+		if err := fn(major, minor, values); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
@@ -1,6 +1,7 @@
 package cgroups
 
 import (
+	"math"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -58,6 +59,13 @@ func TestLoadV2(t *testing.T) {
 }
 
 func TestStatV2(t *testing.T) {
+	mockSysDevBlock(t)
+	cgroupFs := t.TempDir()
+	ioStat := []byte("253:0 rbytes=1000 wbytes=2000 rios=100 wios=200 dbytes=0 dios=0\n")
+	if err := os.WriteFile(filepath.Join(cgroupFs, "io.stat"), ioStat, 0644); err != nil {
+		t.Fatalf("Failed to write io.stat: %v", err)
+	}
+
 	MockCgroupV2 := &MockCgroupV2{
 		MockStatVal: &v2.Metrics{
 			CPU: &v2.CPUStat{
@@ -73,17 +81,6 @@ func TestStatV2(t *testing.T) {
 					Pagesize: "2MB",
 				},
 			},
-			Io: &v2.IOStat{
-				Usage: []*v2.IOEntry{
-					{
-						Major:  253,
-						Rbytes: 1000,
-						Rios:   100,
-						Wbytes: 2000,
-						Wios:   200,
-					},
-				},
-			},
 			Memory: &v2.MemoryStat{
 				ActiveAnon:   1,
 				ActiveFile:   2,
@@ -104,6 +101,7 @@ func TestStatV2(t *testing.T) {
 		MockStatErr: nil,
 	}
 	cgroup := &CgroupV2{
+		root:        cgroupFs,
 		cgroup:      MockCgroupV2,
 		controllers: []string{"cpu", "hugetlb", "io", "memory", "pids"},
 	}
@@ -174,3 +172,94 @@ func TestCpuCountV2(t *testing.T) {
 		t.Errorf("CpuCount() = %d, want %d", got, want)
 	}
 }
+
+func TestReadIoStat(t *testing.T) {
+	mockSysDevBlock(t)
+	ioStatPath := filepath.Join(t.TempDir(), "io.stat")
+	ioStat := []byte("253:0 rbytes=1000 wbytes=2000 rios=100 wios=200 dbytes=300 dios=3\n259:1 rbytes=1 wbytes=2 rios=3 wios=4\n")
+	if err := os.WriteFile(ioStatPath, ioStat, 0644); err != nil {
+		t.Fatalf("Failed to write io.stat: %v", err)
+	}
+	want := []IoUsage{
+		{Device: "dm-0", Major: 253, Minor: 0, Dbytes: 300, Dios: 3, Rbytes: 1000, Rios: 100, Wbytes: 2000, Wios: 200},
+		{Device: "", Major: 259, Minor: 1, Rbytes: 1, Rios: 3, Wbytes: 2, Wios: 4},
+	}
+
+	got, err := readIoStat(ioStatPath)
+	if err != nil {
+		t.Fatalf("readIoStat() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readIoStat() = %+v, want %+v", got, want)
+	}
+
+	t.Run("Missing file", func(t *testing.T) {
+		got, err := readIoStat(filepath.Join(t.TempDir(), "io.stat"))
+		if err != nil {
+			t.Fatalf("readIoStat() returned error: %v", err)
+		}
+		if got != nil {
+			t.Errorf("readIoStat() = %+v, want nil", got)
+		}
+	})
+}
+
+func TestReadIoMax(t *testing.T) {
+	mockSysDevBlock(t)
+	ioMaxPath := filepath.Join(t.TempDir(), "io.max")
+	ioMax := []byte("253:0 rbps=1048576 wbps=max riops=max wiops=120\n")
+	if err := os.WriteFile(ioMaxPath, ioMax, 0644); err != nil {
+		t.Fatalf("Failed to write io.max: %v", err)
+	}
+	want := []IoLimit{
+		{Device: "dm-0", Major: 253, Minor: 0, Rbps: 1048576, Riops: math.MaxUint64, Wbps: math.MaxUint64, Wiops: 120},
+	}
+
+	got, err := readIoMax(ioMaxPath)
+	if err != nil {
+		t.Fatalf("readIoMax() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("readIoMax() = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadFreezer(t *testing.T) {
+	tests := []struct {
+		name   string
+		freeze string
+		events string
+		want   string
+	}{
+		{"Thawed", "0\n", "populated 1\nfrozen 0\n", FreezerThawed},
+		{"Freezing", "1\n", "populated 1\nfrozen 0\n", FreezerFreezing},
+		{"Frozen", "1\n", "populated 1\nfrozen 1\n", FreezerFrozen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cgroupPath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(cgroupPath, "cgroup.freeze"), []byte(tt.freeze), 0644); err != nil {
+				t.Fatalf("Failed to write cgroup.freeze: %v", err)
+			}
+			if err := os.WriteFile(filepath.Join(cgroupPath, "cgroup.events"), []byte(tt.events), 0644); err != nil {
+				t.Fatalf("Failed to write cgroup.events: %v", err)
+			}
+
+			got, err := readFreezer(cgroupPath)
+			if err != nil {
+				t.Fatalf("readFreezer() returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("readFreezer() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Root cgroup", func(t *testing.T) {
+		got, err := readFreezer(t.TempDir())
+		if err != nil || got != "" {
+			t.Errorf("readFreezer() = %q, %v, want empty state", got, err)
+		}
+	})
+}
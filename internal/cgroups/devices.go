@@ -0,0 +1,67 @@
+package cgroups
+
+import (
+	"bufio"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// NVIDIA GPUs are character devices 195:N; minors 254 and 255 are
+	// nvidia-modeset and nvidiactl.
+	nvidiaDeviceMajor   = "195"
+	nvidiaMaxGpuMinor   = 253
+	devicesListAllType  = "a"
+	devicesListCharType = "c"
+	devicesListWildcard = "*"
+)
+
+// Returns minors of NVIDIA GPUs allowed by a v1 devices.list, or an empty
+// slice when none are. Wildcard rules allow every GPU on the node so which are
+// in use is unknown, and nil is returned.
+func readDevicesList(path string) ([]int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gpus := []int{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// format: type major:minor access, e.g. "c 195:0 rwm"
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == devicesListAllType {
+			return nil, nil
+		}
+		if fields[0] != devicesListCharType {
+			continue
+		}
+		major, minorStr, found := strings.Cut(fields[1], ":")
+		if !found {
+			continue
+		}
+		if major == devicesListWildcard || (major == nvidiaDeviceMajor && minorStr == devicesListWildcard) {
+			return nil, nil
+		}
+		if major != nvidiaDeviceMajor {
+			continue
+		}
+		minor, err := strconv.Atoi(minorStr)
+		if err != nil || minor > nvidiaMaxGpuMinor {
+			continue
+		}
+		gpus = append(gpus, minor)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	slices.Sort(gpus)
+
+	return slices.Compact(gpus), nil
+}
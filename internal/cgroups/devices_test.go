@@ -0,0 +1,45 @@
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestReadDevicesList(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices string
+		want    []int
+	}{
+		{"Assigned GPUs", "c 1:3 rwm\nc 195:255 rwm\nc 195:3 rw\nc 195:1 rwm\nc 195:254 rwm\n", []int{1, 3}},
+		{"No GPUs", "c 1:3 rwm\nb 8:0 rwm\nc 195:255 rwm\n", []int{}},
+		{"Unrestricted", "a *:* rwm\n", nil},
+		{"Character device wildcard", "c 195:0 rwm\nc *:* rwm\n", nil},
+		{"GPU wildcard", "c 195:* rwm\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devicesList := filepath.Join(t.TempDir(), "devices.list")
+			if err := os.WriteFile(devicesList, []byte(tt.devices), 0644); err != nil {
+				t.Fatalf("Failed to write devices.list: %v", err)
+			}
+
+			got, err := readDevicesList(devicesList)
+			if err != nil {
+				t.Fatalf("readDevicesList() returned error: %v", err)
+			}
+			if !slices.Equal(got, tt.want) || (got == nil) != (tt.want == nil) {
+				t.Errorf("readDevicesList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("Missing file", func(t *testing.T) {
+		if _, err := readDevicesList(filepath.Join(t.TempDir(), "devices.list")); err == nil {
+			t.Errorf("readDevicesList() expected error for missing file")
+		}
+	})
+}
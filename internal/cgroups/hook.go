@@ -0,0 +1,45 @@
+package cgroups
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var (
+	// Config of the pbs_cgroups hook as staged by MoM.
+	pbsCgroupsHookConfig = "mom_priv/hooks/pbs_cgroups.CF"
+	defaultCgroupPrefix  = "pbs_jobs"
+)
+
+type HookConfig struct {
+	CgroupPrefix string `json:"cgroup_prefix"`
+}
+
+// Reads the pbs_cgroups hook config under pbsHome.
+func ReadHookConfig(pbsHome string) (*HookConfig, error) {
+	configPath := filepath.Join(pbsHome, pbsCgroupsHookConfig)
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	hookConfig := &HookConfig{}
+	if err := json.Unmarshal(data, hookConfig); err != nil {
+		return nil, fmt.Errorf("parsing hook config '%s': %w", configPath, err)
+	}
+	if hookConfig.CgroupPrefix == "" {
+		hookConfig.CgroupPrefix = defaultCgroupPrefix
+	}
+
+	return hookConfig, nil
+}
+
+// Path of job cgroups created by the hook relative to the cgroup root.
+func (h *HookConfig) CgroupPath(version string) string {
+	if version == "v2" {
+		return h.CgroupPrefix + ".service/jobs"
+	}
+	return h.CgroupPrefix + ".service/jobid"
+}
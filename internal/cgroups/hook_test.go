@@ -0,0 +1,62 @@
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadHookConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    string
+		wantErr bool
+	}{
+		{"Custom prefix", `{"cgroup_prefix": "pbs", "enabled": true}`, "pbs", false},
+		{"Default prefix", `{"enabled": true}`, "pbs_jobs", false},
+		{"Invalid json", `{"cgroup_prefix": `, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pbsHome := t.TempDir()
+			configPath := filepath.Join(pbsHome, pbsCgroupsHookConfig)
+			if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+				t.Fatalf("Failed to create hooks directory: %v", err)
+			}
+			if err := os.WriteFile(configPath, []byte(tt.config), 0644); err != nil {
+				t.Fatalf("Failed to write hook config: %v", err)
+			}
+
+			got, err := ReadHookConfig(pbsHome)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadHookConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.CgroupPrefix != tt.want {
+				t.Errorf("ReadHookConfig() = %s, want %s", got.CgroupPrefix, tt.want)
+			}
+		})
+	}
+
+	t.Run("Missing config", func(t *testing.T) {
+		if _, err := ReadHookConfig(t.TempDir()); err == nil {
+			t.Errorf("ReadHookConfig() expected error for missing config")
+		}
+	})
+}
+
+func TestHookConfigCgroupPath(t *testing.T) {
+	hookConfig := &HookConfig{CgroupPrefix: "pbs"}
+	tests := map[string]string{
+		"hybrid": "pbs.service/jobid",
+		"v1":     "pbs.service/jobid",
+		"v2":     "pbs.service/jobs",
+	}
+
+	for version, want := range tests {
+		if got := hookConfig.CgroupPath(version); got != want {
+			t.Errorf("CgroupPath(%s) = %s, want %s", version, got, want)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+package cgroups
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Lifecycle of a job cgroup observed with inotify.
+type Lifecycle struct {
+	Created   time.Time
+	HasEvents bool // populated is only known when cgroup.events is available (v2)
+	Populated bool
+	Removed   time.Time
+}
+
+var (
+	lifecycleWatcherMinBackoff = time.Second
+	lifecycleWatcherMaxBackoff = 5 * time.Minute
+)
+
+// Health of the lifecycle watcher; lifecycles are not updated while it is down.
+type LifecycleHealth struct {
+	Restarts int
+	Up       bool
+}
+
+// LifecycleTracker watches the job cgroup directory for cgroups being created
+// and removed, and the cgroup.events file of each job cgroup for changes to
+// its populated flag. Removed cgroups are kept for the timeout.
+type LifecycleTracker struct {
+	cgroups    map[string]*Lifecycle
+	dir        string
+	health     LifecycleHealth
+	logger     *slog.Logger
+	manager    CgroupManager
+	maxBackoff time.Duration
+	minBackoff time.Duration
+	mu         sync.RWMutex
+	path       string
+	timeout    time.Duration
+	watcher    *fsnotify.Watcher
+}
+
+// Returns a tracker of the cgroups in path. When the job cgroup directory can
+// not be watched yet the tracker is down until Watch starts it.
+func NewLifecycleTracker(manager CgroupManager, path string, timeout time.Duration, logger *slog.Logger) *LifecycleTracker {
+	tracker := &LifecycleTracker{
+		cgroups:    make(map[string]*Lifecycle),
+		dir:        manager.Dir(path),
+		logger:     logger,
+		manager:    manager,
+		maxBackoff: lifecycleWatcherMaxBackoff,
+		minBackoff: lifecycleWatcherMinBackoff,
+		path:       filepath.Join("/", path),
+		timeout:    timeout,
+	}
+	if err := tracker.startWatcher(time.Now()); err != nil {
+		logger.Warn("Cgroup watcher not started", "err", err)
+	}
+
+	return tracker
+}
+
+// Watches the job cgroup directory and tracks the cgroups in it. Cgroups
+// created while nothing was watching use the directory mtime, and tracked
+// cgroups which no longer exist are removed at now.
+func (t *LifecycleTracker) startWatcher(now time.Time) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed creating cgroup watcher: %w", err)
+	}
+	if err := watcher.Add(t.dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed watching cgroup directory %s: %w", t.dir, err)
+	}
+
+	cgroupPaths, err := t.manager.List(t.path)
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+
+	t.mu.Lock()
+	t.watcher = watcher
+	t.health.Up = true
+	t.mu.Unlock()
+
+	exists := make(map[string]bool, len(cgroupPaths))
+	for _, cgroupPath := range cgroupPaths {
+		name := filepath.Base(cgroupPath)
+		exists[name] = true
+		if lifecycle, tracked := t.Get(cgroupPath); tracked && lifecycle.Removed.IsZero() {
+			t.watchCgroup(name)
+			t.updateEvents(name)
+			continue
+		}
+		created, err := t.manager.Created(cgroupPath)
+		if err != nil {
+			continue
+		}
+		t.created(name, created)
+	}
+
+	t.mu.RLock()
+	var removed []string
+	for cgroupPath, lifecycle := range t.cgroups {
+		if name := filepath.Base(cgroupPath); !exists[name] && lifecycle.Removed.IsZero() {
+			removed = append(removed, name)
+		}
+	}
+	t.mu.RUnlock()
+	for _, name := range removed {
+		t.removed(name, now)
+	}
+
+	return nil
+}
+
+func (t *LifecycleTracker) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.watcher == nil {
+		return nil
+	}
+	t.health.Up = false
+	err := t.watcher.Close()
+	t.watcher = nil
+
+	return err
+}
+
+func (t *LifecycleTracker) Health() LifecycleHealth {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.health
+}
+
+// Returns the lifecycle of a cgroup by path, e.g. /pbs_jobs.service/jobs/1000.pbs.
+func (t *LifecycleTracker) Get(cgroupPath string) (Lifecycle, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	lifecycle, exists := t.cgroups[cgroupPath]
+	if !exists {
+		return Lifecycle{}, false
+	}
+
+	return *lifecycle, true
+}
+
+// Processes watcher events until the context is cancelled. When the watcher
+// stops it is restarted with backoff, and the tracked cgroups are resynced
+// with the job cgroup directory.
+func (t *LifecycleTracker) Watch(ctx context.Context) error {
+	backoff := t.minBackoff
+	for {
+		started := time.Now()
+		err := t.watchEvents(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// a watcher which ran for a while failed on its own, not on restart
+		if time.Since(started) > t.maxBackoff {
+			backoff = t.minBackoff
+		}
+		t.logger.Error("Cgroup watcher stopped", "err", err, "restart", backoff)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(backoff):
+		}
+		t.mu.Lock()
+		t.health.Restarts++
+		t.mu.Unlock()
+		backoff = min(2*backoff, t.maxBackoff)
+	}
+}
+
+func (t *LifecycleTracker) watchEvents(ctx context.Context) error {
+	t.mu.RLock()
+	watcher := t.watcher
+	t.mu.RUnlock()
+	if watcher == nil {
+		if err := t.startWatcher(time.Now()); err != nil {
+			return err
+		}
+		t.mu.RLock()
+		watcher = t.watcher
+		t.mu.RUnlock()
+	}
+	defer t.Close()
+
+	// closing the watcher ends the event loop
+	stop := context.AfterFunc(ctx, func() { watcher.Close() })
+	defer stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return fmt.Errorf("cgroup watcher events channel closed")
+			}
+			t.handleEvent(event, time.Now())
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return fmt.Errorf("cgroup watcher errors channel closed")
+			}
+			// events were dropped, restart to resync tracked cgroups
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				return err
+			}
+			t.logger.Error("Cgroup watcher error", "err", err)
+		}
+	}
+}
+
+func (t *LifecycleTracker) handleEvent(event fsnotify.Event, now time.Time) {
+	parent, name := filepath.Split(event.Name)
+	parent = filepath.Clean(parent)
+
+	switch {
+	case parent == t.dir && event.Has(fsnotify.Create):
+		t.logger.Debug("Cgroup created", "name", name)
+		t.created(name, now)
+	case parent == t.dir && event.Has(fsnotify.Remove):
+		t.logger.Debug("Cgroup removed", "name", name)
+		t.removed(name, now)
+	case filepath.Dir(parent) == t.dir && name == "cgroup.events" && event.Has(fsnotify.Write):
+		t.updateEvents(filepath.Base(parent))
+	}
+}
+
+// Watches the cgroup for changes to cgroup.events.
+func (t *LifecycleTracker) watchCgroup(name string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.watcher == nil {
+		return
+	}
+	if err := t.watcher.Add(filepath.Join(t.dir, name)); err != nil {
+		t.logger.Debug("Failed watching cgroup", "name", name, "err", err)
+	}
+}
+
+func (t *LifecycleTracker) created(name string, created time.Time) {
+	t.watchCgroup(name)
+
+	t.mu.Lock()
+	t.cgroups[filepath.Join(t.path, name)] = &Lifecycle{Created: created}
+	t.mu.Unlock()
+
+	t.updateEvents(name)
+}
+
+func (t *LifecycleTracker) removed(name string, removed time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if lifecycle, exists := t.cgroups[filepath.Join(t.path, name)]; exists {
+		lifecycle.Populated = false
+		lifecycle.Removed = removed
+	}
+
+	for cgroupPath, lifecycle := range t.cgroups {
+		if !lifecycle.Removed.IsZero() && removed.Sub(lifecycle.Removed) > t.timeout {
+			delete(t.cgroups, cgroupPath)
+		}
+	}
+}
+
+func (t *LifecycleTracker) updateEvents(name string) {
+	events, err := readFlatKeyed(filepath.Join(t.dir, name, "cgroup.events"))
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if lifecycle, exists := t.cgroups[filepath.Join(t.path, name)]; exists {
+		lifecycle.HasEvents = true
+		lifecycle.Populated = events["populated"] == "1"
+	}
+}
@@ -0,0 +1,172 @@
+package cgroups
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/common/promslog"
+)
+
+func mockJobCgroup(t *testing.T, dir string, name string, populated string) {
+	t.Helper()
+	cgroupPath := filepath.Join(dir, name)
+	if err := os.MkdirAll(cgroupPath, 0755); err != nil {
+		t.Fatalf("Failed to create cgroup directory: %v", err)
+	}
+	events := []byte("populated " + populated + "\nfrozen 0\n")
+	if err := os.WriteFile(filepath.Join(cgroupPath, "cgroup.events"), events, 0644); err != nil {
+		t.Fatalf("Failed to write cgroup.events: %v", err)
+	}
+}
+
+func TestLifecycleTracker(t *testing.T) {
+	cgroupRoot := t.TempDir()
+	jobsDir := filepath.Join(cgroupRoot, "pbs_jobs.service", "jobs")
+	mockJobCgroup(t, jobsDir, "1000.pbs", "1")
+
+	manager := &CgroupV2Manager{root: cgroupRoot}
+	tracker := NewLifecycleTracker(manager, "pbs_jobs.service/jobs", time.Minute, promslog.NewNopLogger())
+	t.Cleanup(func() { tracker.Close() })
+
+	t.Run("Existing cgroup", func(t *testing.T) {
+		got, exists := tracker.Get("/pbs_jobs.service/jobs/1000.pbs")
+		if !exists || got.Created.IsZero() || !got.HasEvents || !got.Populated {
+			t.Errorf("Get() = %+v, %v, want created and populated", got, exists)
+		}
+	})
+
+	created := time.Now()
+	t.Run("Created", func(t *testing.T) {
+		mockJobCgroup(t, jobsDir, "1001.pbs", "0")
+		tracker.handleEvent(fsnotify.Event{Name: filepath.Join(jobsDir, "1001.pbs"), Op: fsnotify.Create}, created)
+
+		got, exists := tracker.Get("/pbs_jobs.service/jobs/1001.pbs")
+		if !exists || !got.Created.Equal(created) || got.Populated {
+			t.Errorf("Get() = %+v, %v, want created at %v and unpopulated", got, exists, created)
+		}
+	})
+
+	t.Run("Populated", func(t *testing.T) {
+		mockJobCgroup(t, jobsDir, "1001.pbs", "1")
+		tracker.handleEvent(fsnotify.Event{Name: filepath.Join(jobsDir, "1001.pbs", "cgroup.events"), Op: fsnotify.Write}, time.Now())
+
+		got, _ := tracker.Get("/pbs_jobs.service/jobs/1001.pbs")
+		if !got.Populated {
+			t.Errorf("Get() = %+v, want populated", got)
+		}
+	})
+
+	t.Run("Removed", func(t *testing.T) {
+		removed := created.Add(time.Second)
+		tracker.handleEvent(fsnotify.Event{Name: filepath.Join(jobsDir, "1001.pbs"), Op: fsnotify.Remove}, removed)
+
+		got, exists := tracker.Get("/pbs_jobs.service/jobs/1001.pbs")
+		if !exists || !got.Removed.Equal(removed) || got.Populated {
+			t.Errorf("Get() = %+v, %v, want removed at %v", got, exists, removed)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		tracker.handleEvent(fsnotify.Event{Name: filepath.Join(jobsDir, "1000.pbs"), Op: fsnotify.Remove}, created.Add(2*time.Minute))
+
+		if _, exists := tracker.Get("/pbs_jobs.service/jobs/1001.pbs"); exists {
+			t.Errorf("Get() expected expired cgroup to be removed")
+		}
+	})
+}
+
+// Waits for the condition to hold, failing the test after a second.
+func waitFor(t *testing.T, name string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", name)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLifecycleTrackerWatch(t *testing.T) {
+	cgroupRoot := t.TempDir()
+	jobsDir := filepath.Join(cgroupRoot, "pbs_jobs.service", "jobs")
+	mockJobCgroup(t, jobsDir, "1000.pbs", "1")
+
+	manager := &CgroupV2Manager{root: cgroupRoot}
+	tracker := NewLifecycleTracker(manager, "pbs_jobs.service/jobs", time.Minute, promslog.NewNopLogger())
+	tracker.minBackoff = 20 * time.Millisecond
+	tracker.maxBackoff = 50 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- tracker.Watch(ctx) }()
+
+	mockJobCgroup(t, jobsDir, "1001.pbs", "1")
+	waitFor(t, "created cgroup", func() bool {
+		_, exists := tracker.Get("/pbs_jobs.service/jobs/1001.pbs")
+		return exists
+	})
+
+	// the watcher stops, cgroups change while it is down, and it is restarted
+	tracker.Close()
+	if err := os.RemoveAll(filepath.Join(jobsDir, "1000.pbs")); err != nil {
+		t.Fatalf("Failed to remove cgroup directory: %v", err)
+	}
+	mockJobCgroup(t, jobsDir, "1002.pbs", "0")
+	waitFor(t, "watcher restarted", func() bool {
+		health := tracker.Health()
+		return health.Up && health.Restarts == 1
+	})
+
+	if got, exists := tracker.Get("/pbs_jobs.service/jobs/1000.pbs"); !exists || got.Removed.IsZero() {
+		t.Errorf("Get() = %+v, %v, want cgroup removed while watcher was down", got, exists)
+	}
+	if got, exists := tracker.Get("/pbs_jobs.service/jobs/1002.pbs"); !exists || got.Created.IsZero() || got.Populated {
+		t.Errorf("Get() = %+v, %v, want cgroup created while watcher was down", got, exists)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Watch() returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch() did not return after the context was cancelled")
+	}
+	if tracker.Health().Up {
+		t.Errorf("Watch() left watcher up after returning")
+	}
+}
+
+// the job cgroup directory is created after the tracker
+func TestLifecycleTrackerMissingDir(t *testing.T) {
+	cgroupRoot := t.TempDir()
+	manager := &CgroupV2Manager{root: cgroupRoot}
+	tracker := NewLifecycleTracker(manager, "pbs_jobs.service/jobs", time.Minute, promslog.NewNopLogger())
+	tracker.minBackoff = 20 * time.Millisecond
+	tracker.maxBackoff = 50 * time.Millisecond
+	if tracker.Health().Up {
+		t.Errorf("NewLifecycleTracker() watcher up for missing directory")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- tracker.Watch(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	mockJobCgroup(t, filepath.Join(cgroupRoot, "pbs_jobs.service", "jobs"), "1000.pbs", "1")
+	waitFor(t, "watcher started", func() bool {
+		return tracker.Health().Up
+	})
+	if got, exists := tracker.Get("/pbs_jobs.service/jobs/1000.pbs"); !exists || !got.Populated {
+		t.Errorf("Get() = %+v, %v, want cgroup created before watcher started", got, exists)
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"golang.org/x/sync/errgroup"
 	"log/slog"
+	"math"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/0nebody/pbs_exporter/internal/process"
 	"github.com/0nebody/pbs_exporter/internal/utils"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -15,22 +18,44 @@ import (
 type CgroupCollector struct {
 	cgroupPath          string
 	cgroupRoot          string
+	fdMetrics           *FdMetrics
+	gpuMetrics          *GpuMetrics
+	grace               *graceCache
 	jobCollectorEnabled bool
+	lifecycleMetrics    *LifecycleMetrics
 	logger              *slog.Logger
 	metrics             *CgroupMetrics
+	nestedDepth         int
+	nestedMetrics       *NestedMetrics
+	outside             *outsideCounters
+	procFs              *process.ProcFS
+	processMetrics      *ProcessMetrics
+	processTopN         int
+	pss                 *pssCache
+	pssMetrics          *PssMetrics
+	sliceMetrics        *SliceMetrics
+	stateMetrics        *StateMetrics
 }
 
 type CgroupMetrics struct {
 	cpuCountDesc        *prometheus.Desc
+	cpusetInfoDesc      *prometheus.Desc
 	cpuSystemDesc       *prometheus.Desc
 	cpuUsageDesc        *prometheus.Desc
 	cpuUserDesc         *prometheus.Desc
+	freezerStateDesc    *prometheus.Desc
 	hugetlbFailCntDesc  *prometheus.Desc
 	hugetlbMaxDesc      *prometheus.Desc
 	hugetlbUsageDesc    *prometheus.Desc
+	ioDbytesDesc        *prometheus.Desc
+	ioDiosDesc          *prometheus.Desc
+	ioRbpsLimitDesc     *prometheus.Desc
 	ioRbytesDesc        *prometheus.Desc
+	ioRiopsLimitDesc    *prometheus.Desc
 	ioRiosDesc          *prometheus.Desc
+	ioWbpsLimitDesc     *prometheus.Desc
 	ioWbytesDesc        *prometheus.Desc
+	ioWiopsLimitDesc    *prometheus.Desc
 	ioWiosDesc          *prometheus.Desc
 	memActiveAnonDesc   *prometheus.Desc
 	memActiveFileDesc   *prometheus.Desc
@@ -46,14 +71,20 @@ type CgroupMetrics struct {
 	memSwapUsageDesc    *prometheus.Desc
 	memUsageDesc        *prometheus.Desc
 	memWssDesc          *prometheus.Desc
+	orphanedAgeDesc     *prometheus.Desc
+	orphanedDesc        *prometheus.Desc
+	orphanedMemDesc     *prometheus.Desc
+	orphanedPidsDesc    *prometheus.Desc
 	pidLimitDesc        *prometheus.Desc
 	pidUsageDesc        *prometheus.Desc
+	suspendedDesc       *prometheus.Desc
 	threadUsageDesc     *prometheus.Desc
+	unmatchedDesc       *prometheus.Desc
 }
 
 func NewCgroupCollector(config CollectorConfig) *CgroupCollector {
 	hugetlbJobLabels := append(defaultJobLabels, "hugetlb_pagesize")
-	ioJobLabels := append(defaultJobLabels, "major")
+	ioJobLabels := append(defaultJobLabels, "device", "major", "minor")
 	cgroupMetrics := &CgroupMetrics{
 		cpuCountDesc: prometheus.NewDesc(
 			"pbs_cgroup_cpus",
@@ -61,6 +92,12 @@ func NewCgroupCollector(config CollectorConfig) *CgroupCollector {
 			defaultJobLabels,
 			nil,
 		),
+		cpusetInfoDesc: prometheus.NewDesc(
+			"pbs_cgroup_cpuset_info",
+			"CPUs and memory nodes assigned to the cgroup in cpuset list format.",
+			append(defaultJobLabels, "cpus", "mems"),
+			nil,
+		),
 		cpuSystemDesc: prometheus.NewDesc(
 			"pbs_cgroup_cpu_system_seconds_total",
 			"Total system CPU time in seconds consumed by tasks in the cgroup.",
@@ -79,6 +116,12 @@ func NewCgroupCollector(config CollectorConfig) *CgroupCollector {
 			defaultJobLabels,
 			nil,
 		),
+		freezerStateDesc: prometheus.NewDesc(
+			"pbs_cgroup_freezer_state",
+			"Freezer state of the cgroup, one of thawed, freezing or frozen.",
+			append(defaultJobLabels, "state"),
+			nil,
+		),
 		hugetlbFailCntDesc: prometheus.NewDesc(
 			"pbs_cgroup_hugetlb_failcnt_total",
 			"Total number of hugetlb faults incurred.",
@@ -97,26 +140,62 @@ func NewCgroupCollector(config CollectorConfig) *CgroupCollector {
 			hugetlbJobLabels,
 			nil,
 		),
+		ioDbytesDesc: prometheus.NewDesc(
+			"pbs_cgroup_io_discard_bytes_total",
+			"Total bytes discarded by tasks in the cgroup.",
+			ioJobLabels,
+			nil,
+		),
+		ioDiosDesc: prometheus.NewDesc(
+			"pbs_cgroup_io_discards_total",
+			"Total discard IO operations performed by tasks in the cgroup.",
+			ioJobLabels,
+			nil,
+		),
+		ioRbpsLimitDesc: prometheus.NewDesc(
+			"pbs_cgroup_io_max_read_bytes_per_second",
+			"Read bandwidth limit of the cgroup for the device.",
+			ioJobLabels,
+			nil,
+		),
 		ioRbytesDesc: prometheus.NewDesc(
-			"pbs_cgroup_io_rbytes_bytes",
+			"pbs_cgroup_io_read_bytes_total",
 			"Total bytes read by tasks in the cgroup.",
 			ioJobLabels,
 			nil,
 		),
+		ioRiopsLimitDesc: prometheus.NewDesc(
+			"pbs_cgroup_io_max_read_ios_per_second",
+			"Read IO operations per second limit of the cgroup for the device.",
+			ioJobLabels,
+			nil,
+		),
 		ioRiosDesc: prometheus.NewDesc(
-			"pbs_cgroup_io_rios_total",
+			"pbs_cgroup_io_reads_total",
 			"Total read IO operations performed by tasks in the cgroup.",
 			ioJobLabels,
 			nil,
 		),
+		ioWbpsLimitDesc: prometheus.NewDesc(
+			"pbs_cgroup_io_max_write_bytes_per_second",
+			"Write bandwidth limit of the cgroup for the device.",
+			ioJobLabels,
+			nil,
+		),
 		ioWbytesDesc: prometheus.NewDesc(
-			"pbs_cgroup_io_wbytes_bytes",
+			"pbs_cgroup_io_write_bytes_total",
 			"Total bytes written by tasks in the cgroup.",
 			ioJobLabels,
 			nil,
 		),
+		ioWiopsLimitDesc: prometheus.NewDesc(
+			"pbs_cgroup_io_max_write_ios_per_second",
+			"Write IO operations per second limit of the cgroup for the device.",
+			ioJobLabels,
+			nil,
+		),
 		ioWiosDesc: prometheus.NewDesc(
-			"pbs_cgroup_io_wios_total",
+			"pbs_cgroup_io_writes_total",
 			"Total write IO operations performed by tasks in the cgroup.",
 			ioJobLabels,
 			nil,
@@ -205,6 +284,30 @@ func NewCgroupCollector(config CollectorConfig) *CgroupCollector {
 			defaultJobLabels,
 			nil,
 		),
+		orphanedAgeDesc: prometheus.NewDesc(
+			"pbs_cgroup_orphaned_age_seconds",
+			"Seconds since the orphaned cgroup was created. Uses the cgroup directory mtime, which nested cgroup changes reset, unless --cgroup.lifecycle is enabled.",
+			[]string{"jobid"},
+			nil,
+		),
+		orphanedDesc: prometheus.NewDesc(
+			"pbs_cgroup_orphaned",
+			"Job cgroup without a job file; the job has ended or the cgroup was leaked.",
+			[]string{"jobid"},
+			nil,
+		),
+		orphanedMemDesc: prometheus.NewDesc(
+			"pbs_cgroup_orphaned_mem_usage_bytes",
+			"Total memory used by tasks in the orphaned cgroup.",
+			[]string{"jobid"},
+			nil,
+		),
+		orphanedPidsDesc: prometheus.NewDesc(
+			"pbs_cgroup_orphaned_pids",
+			"Number of processes in the orphaned cgroup.",
+			[]string{"jobid"},
+			nil,
+		),
 		pidLimitDesc: prometheus.NewDesc(
 			"pbs_cgroup_pid_limit",
 			"PID limit of cgroup.",
@@ -217,34 +320,98 @@ func NewCgroupCollector(config CollectorConfig) *CgroupCollector {
 			defaultJobLabels,
 			nil,
 		),
+		suspendedDesc: prometheus.NewDesc(
+			"pbs_cgroup_suspended",
+			"Whether the job is suspended, by its job file state or a frozen cgroup.",
+			defaultJobLabels,
+			nil,
+		),
 		threadUsageDesc: prometheus.NewDesc(
 			"pbs_cgroup_thread_usage",
 			"Number of threads used by the cgroup.",
 			defaultJobLabels,
 			nil,
 		),
+		unmatchedDesc: prometheus.NewDesc(
+			"pbs_cgroup_unmatched",
+			"Cgroup in the PBS jobs cgroup without a job ID.",
+			[]string{"cgroup"},
+			nil,
+		),
 	}
 
-	return &CgroupCollector{
+	cgroupCollector := &CgroupCollector{
 		cgroupPath:          config.CgroupPath,
 		cgroupRoot:          config.CgroupRoot,
 		jobCollectorEnabled: config.EnableJobCollector,
 		logger:              config.Logger,
 		metrics:             cgroupMetrics,
 	}
+
+	if config.GracePeriod > 0 {
+		cgroupCollector.grace = newGraceCache(config.GracePeriod)
+	}
+
+	// per-process metrics are optional; disabled when procfs is unavailable
+	if config.ProcessTopN > 0 || config.PssInterval > 0 || config.EnableProcessStates || config.EnableFileDescriptors || config.EnableGpuInfo {
+		procFs, err := process.NewProcFS(config.ProcRoot)
+		if err != nil {
+			config.Logger.Error("Process metrics disabled, unable to open procfs", "err", err, "path", config.ProcRoot)
+		} else {
+			cgroupCollector.procFs = procFs
+		}
+	}
+	if cgroupCollector.procFs != nil && config.ProcessTopN > 0 {
+		cgroupCollector.processMetrics = NewProcessMetrics()
+		cgroupCollector.processTopN = config.ProcessTopN
+	}
+	if cgroupCollector.procFs != nil && config.PssInterval > 0 {
+		cgroupCollector.pss = newPssCache(config.PssInterval)
+		cgroupCollector.pssMetrics = NewPssMetrics()
+	}
+	if cgroupCollector.procFs != nil && config.EnableProcessStates {
+		cgroupCollector.stateMetrics = NewStateMetrics()
+	}
+	if cgroupCollector.procFs != nil && config.EnableFileDescriptors {
+		cgroupCollector.fdMetrics = NewFdMetrics()
+	}
+	if config.EnableGpuInfo {
+		cgroupCollector.gpuMetrics = NewGpuMetrics()
+	}
+	if config.EnableLifecycle {
+		cgroupCollector.lifecycleMetrics = NewLifecycleMetrics()
+	}
+	if config.NestedDepth > 0 {
+		cgroupCollector.nestedDepth = config.NestedDepth
+		cgroupCollector.nestedMetrics = NewNestedMetrics()
+	}
+	if config.EnableSliceMetrics {
+		cgroupCollector.outside = &outsideCounters{}
+		cgroupCollector.sliceMetrics = NewSliceMetrics()
+	}
+
+	return cgroupCollector
 }
 
 func (c *CgroupCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.metrics.cpuCountDesc
+	ch <- c.metrics.cpusetInfoDesc
 	ch <- c.metrics.cpuSystemDesc
 	ch <- c.metrics.cpuUsageDesc
 	ch <- c.metrics.cpuUserDesc
+	ch <- c.metrics.freezerStateDesc
 	ch <- c.metrics.hugetlbFailCntDesc
 	ch <- c.metrics.hugetlbMaxDesc
 	ch <- c.metrics.hugetlbUsageDesc
+	ch <- c.metrics.ioDbytesDesc
+	ch <- c.metrics.ioDiosDesc
+	ch <- c.metrics.ioRbpsLimitDesc
 	ch <- c.metrics.ioRbytesDesc
+	ch <- c.metrics.ioRiopsLimitDesc
 	ch <- c.metrics.ioRiosDesc
+	ch <- c.metrics.ioWbpsLimitDesc
 	ch <- c.metrics.ioWbytesDesc
+	ch <- c.metrics.ioWiopsLimitDesc
 	ch <- c.metrics.ioWiosDesc
 	ch <- c.metrics.memActiveAnonDesc
 	ch <- c.metrics.memActiveFileDesc
@@ -260,9 +427,47 @@ func (c *CgroupCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.metrics.memSwapUsageDesc
 	ch <- c.metrics.memUsageDesc
 	ch <- c.metrics.memWssDesc
+	ch <- c.metrics.orphanedAgeDesc
+	ch <- c.metrics.orphanedDesc
+	ch <- c.metrics.orphanedMemDesc
+	ch <- c.metrics.orphanedPidsDesc
 	ch <- c.metrics.pidLimitDesc
 	ch <- c.metrics.pidUsageDesc
+	ch <- c.metrics.suspendedDesc
 	ch <- c.metrics.threadUsageDesc
+	ch <- c.metrics.unmatchedDesc
+	if c.processMetrics != nil {
+		c.processMetrics.Describe(ch)
+	}
+	if c.pssMetrics != nil {
+		c.pssMetrics.Describe(ch)
+	}
+	if c.stateMetrics != nil {
+		c.stateMetrics.Describe(ch)
+	}
+	if c.fdMetrics != nil {
+		c.fdMetrics.Describe(ch)
+	}
+	if c.gpuMetrics != nil {
+		c.gpuMetrics.Describe(ch)
+	}
+	if c.lifecycleMetrics != nil {
+		c.lifecycleMetrics.Describe(ch)
+	}
+	if c.nestedMetrics != nil {
+		c.nestedMetrics.Describe(ch)
+	}
+	if c.sliceMetrics != nil {
+		c.sliceMetrics.Describe(ch)
+	}
+}
+
+func ioDeviceLabels(device string, major uint64, minor uint64) []string {
+	return []string{
+		device,
+		strconv.FormatUint(major, 10),
+		strconv.FormatUint(minor, 10),
+	}
 }
 
 func getCgroupStats(ctx context.Context, root string, path string, logger *slog.Logger) ([]*cgroups.Metrics, error) {
@@ -315,22 +520,39 @@ func getCgroupStats(ctx context.Context, root string, path string, logger *slog.
 }
 
 func (c *CgroupCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) {
+	if c.sliceMetrics != nil {
+		c.collectSlices(ctx, ch)
+	}
+	if c.lifecycleMetrics != nil {
+		c.collectLifecycleWatcher(ch)
+	}
+
 	metrics, err := getCgroupStats(ctx, c.cgroupRoot, c.cgroupPath, c.logger)
 	if err != nil {
 		c.logger.Error("Failed to get cgroup metrics", "err", err)
 		return
 	}
 
+	seen := make(map[string]bool, len(metrics))
+	defer c.collectEnded(ch, seen)
+
 	for _, metric := range metrics {
 		// skip jobs with no id
 		jobId := utils.GetCgroupJobId(metric.Path)
 		if jobId == "" {
-			c.logger.Error("Job ID empty", "cgroupPath", metric.Path)
+			c.logger.Debug("Job ID empty", "cgroupPath", metric.Path)
+			ch <- prometheus.MustNewConstMetric(
+				c.metrics.unmatchedDesc,
+				prometheus.GaugeValue,
+				1,
+				metric.Path,
+			)
 			continue
 		}
 
-		// skip when job collector enabled but no job file for cgroup; cgroup is orphaned or being deleted.
+		// report as orphaned when job collector enabled but no job file for cgroup; cgroup is leaked or being deleted.
 		jobRunCount := ""
+		jobSuspended := false
 		if c.jobCollectorEnabled {
 			if jobCache == nil {
 				c.logger.Error("Job cache is uninitialised")
@@ -338,13 +560,19 @@ func (c *CgroupCollector) Collect(ctx context.Context, ch chan<- prometheus.Metr
 			}
 			if job, exists := jobCache.Get(jobId); exists {
 				jobRunCount = strconv.Itoa(job.RunCount)
+				jobSuspended = job.IsSuspended()
 			} else {
-				c.logger.Error("Job file not found", "jobId", jobId)
+				c.logger.Debug("Job file not found", "jobId", jobId)
+				c.collectOrphaned(ch, metric, jobId)
 				continue
 			}
 		}
 
 		jobLabels := []string{jobId, jobRunCount}
+		if c.grace != nil {
+			c.grace.set(metric, jobLabels)
+		}
+		seen[metric.Path] = true
 
 		ch <- prometheus.MustNewConstMetric(
 			c.metrics.cpuCountDesc,
@@ -353,23 +581,12 @@ func (c *CgroupCollector) Collect(ctx context.Context, ch chan<- prometheus.Metr
 			jobLabels...,
 		)
 		ch <- prometheus.MustNewConstMetric(
-			c.metrics.cpuSystemDesc,
-			prometheus.CounterValue,
-			float64(metric.Cpu.System),
-			jobLabels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			c.metrics.cpuUsageDesc,
-			prometheus.CounterValue,
-			float64(metric.Cpu.Usage),
-			jobLabels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			c.metrics.cpuUserDesc,
-			prometheus.CounterValue,
-			float64(metric.Cpu.User),
-			jobLabels...,
+			c.metrics.cpusetInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			append(jobLabels, utils.FormatListFormat(metric.Cpu.Cpus), utils.FormatListFormat(metric.Cpu.Mems))...,
 		)
+		c.collectCounters(ch, metric, jobLabels)
 		ch <- prometheus.MustNewConstMetric(
 			c.metrics.memActiveAnonDesc,
 			prometheus.GaugeValue,
@@ -406,18 +623,6 @@ func (c *CgroupCollector) Collect(ctx context.Context, ch chan<- prometheus.Metr
 			float64(metric.Memory.Limit),
 			jobLabels...,
 		)
-		ch <- prometheus.MustNewConstMetric(
-			c.metrics.memPgfaultDesc,
-			prometheus.CounterValue,
-			float64(metric.Memory.Pgfault),
-			jobLabels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			c.metrics.memPgmajfaultDesc,
-			prometheus.CounterValue,
-			float64(metric.Memory.Pgmajfault),
-			jobLabels...,
-		)
 		ch <- prometheus.MustNewConstMetric(
 			c.metrics.memRssDesc,
 			prometheus.GaugeValue,
@@ -454,33 +659,29 @@ func (c *CgroupCollector) Collect(ctx context.Context, ch chan<- prometheus.Metr
 			float64(metric.Memory.Wss),
 			jobLabels...,
 		)
-		for _, ioUsage := range metric.Io.Usage {
-			major := strconv.FormatUint(ioUsage.Major, 10)
-			ioLabels := append(jobLabels, major)
-			ch <- prometheus.MustNewConstMetric(
-				c.metrics.ioRbytesDesc,
-				prometheus.GaugeValue,
-				float64(ioUsage.Rbytes),
-				ioLabels...,
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.metrics.ioRiosDesc,
-				prometheus.GaugeValue,
-				float64(ioUsage.Rios),
-				ioLabels...,
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.metrics.ioWbytesDesc,
-				prometheus.GaugeValue,
-				float64(ioUsage.Wbytes),
-				ioLabels...,
-			)
-			ch <- prometheus.MustNewConstMetric(
-				c.metrics.ioWiosDesc,
-				prometheus.GaugeValue,
-				float64(ioUsage.Wios),
-				ioLabels...,
-			)
+		// unlimited values are skipped
+		for _, ioLimit := range metric.Io.Limits {
+			ioLabels := append(jobLabels, ioDeviceLabels(ioLimit.Device, ioLimit.Major, ioLimit.Minor)...)
+			ioLimitDescs := []struct {
+				desc  *prometheus.Desc
+				value uint64
+			}{
+				{c.metrics.ioRbpsLimitDesc, ioLimit.Rbps},
+				{c.metrics.ioRiopsLimitDesc, ioLimit.Riops},
+				{c.metrics.ioWbpsLimitDesc, ioLimit.Wbps},
+				{c.metrics.ioWiopsLimitDesc, ioLimit.Wiops},
+			}
+			for _, ioLimitDesc := range ioLimitDescs {
+				if ioLimitDesc.value == math.MaxUint64 {
+					continue
+				}
+				ch <- prometheus.MustNewConstMetric(
+					ioLimitDesc.desc,
+					prometheus.GaugeValue,
+					float64(ioLimitDesc.value),
+					ioLabels...,
+				)
+			}
 		}
 		ch <- prometheus.MustNewConstMetric(
 			c.metrics.pidLimitDesc,
@@ -500,6 +701,28 @@ func (c *CgroupCollector) Collect(ctx context.Context, ch chan<- prometheus.Metr
 			float64(metric.Tasks.ThreadUsage),
 			jobLabels...,
 		)
+		c.collectFreezer(ch, jobLabels, metric.Freezer, jobSuspended)
+		if c.processMetrics != nil {
+			c.collectProcesses(ch, jobLabels, metric.Tasks.Pids)
+		}
+		if c.pssMetrics != nil {
+			c.collectPss(ch, jobLabels, metric.Path, metric.Tasks.Pids)
+		}
+		if c.stateMetrics != nil {
+			c.collectStates(ch, jobLabels, metric.Tasks.Pids, metric.Tasks.Threads)
+		}
+		if c.fdMetrics != nil {
+			c.collectFileDescriptors(ch, jobLabels, metric.Tasks.Pids)
+		}
+		if c.gpuMetrics != nil {
+			c.collectGpus(ch, jobLabels, metric)
+		}
+		if c.lifecycleMetrics != nil {
+			c.collectLifecycle(ch, jobLabels, metric.Path)
+		}
+		if c.nestedMetrics != nil {
+			c.collectNested(ch, jobLabels, metric.Path)
+		}
 		for _, hugetlb := range metric.Hugetlb {
 			hugetlbLabels := append(jobLabels, hugetlb.Pagesize)
 			ch <- prometheus.MustNewConstMetric(
@@ -523,3 +746,149 @@ func (c *CgroupCollector) Collect(ctx context.Context, ch chan<- prometheus.Metr
 		}
 	}
 }
+
+// Counters are also exported for ended jobs from the grace cache.
+func (c *CgroupCollector) collectCounters(ch chan<- prometheus.Metric, metric *cgroups.Metrics, jobLabels []string) {
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.cpuSystemDesc,
+		prometheus.CounterValue,
+		float64(metric.Cpu.System),
+		jobLabels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.cpuUsageDesc,
+		prometheus.CounterValue,
+		float64(metric.Cpu.Usage),
+		jobLabels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.cpuUserDesc,
+		prometheus.CounterValue,
+		float64(metric.Cpu.User),
+		jobLabels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.memPgfaultDesc,
+		prometheus.CounterValue,
+		float64(metric.Memory.Pgfault),
+		jobLabels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.memPgmajfaultDesc,
+		prometheus.CounterValue,
+		float64(metric.Memory.Pgmajfault),
+		jobLabels...,
+	)
+	for _, ioUsage := range metric.Io.Usage {
+		ioLabels := append(jobLabels, ioDeviceLabels(ioUsage.Device, ioUsage.Major, ioUsage.Minor)...)
+		ch <- prometheus.MustNewConstMetric(
+			c.metrics.ioDbytesDesc,
+			prometheus.CounterValue,
+			float64(ioUsage.Dbytes),
+			ioLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.metrics.ioDiosDesc,
+			prometheus.CounterValue,
+			float64(ioUsage.Dios),
+			ioLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.metrics.ioRbytesDesc,
+			prometheus.CounterValue,
+			float64(ioUsage.Rbytes),
+			ioLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.metrics.ioRiosDesc,
+			prometheus.CounterValue,
+			float64(ioUsage.Rios),
+			ioLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.metrics.ioWbytesDesc,
+			prometheus.CounterValue,
+			float64(ioUsage.Wbytes),
+			ioLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.metrics.ioWiosDesc,
+			prometheus.CounterValue,
+			float64(ioUsage.Wios),
+			ioLabels...,
+		)
+	}
+}
+
+func (c *CgroupCollector) collectOrphaned(ch chan<- prometheus.Metric, metric *cgroups.Metrics, jobId string) {
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.orphanedDesc,
+		prometheus.GaugeValue,
+		1,
+		jobId,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.orphanedMemDesc,
+		prometheus.GaugeValue,
+		float64(metric.Memory.Usage),
+		jobId,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.orphanedPidsDesc,
+		prometheus.GaugeValue,
+		float64(metric.Tasks.PidUsage),
+		jobId,
+	)
+
+	created, err := c.cgroupCreated(metric.Path)
+	if err != nil {
+		c.logger.Error("Error getting orphaned cgroup age", "err", err, "cgroupPath", metric.Path)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.orphanedAgeDesc,
+		prometheus.GaugeValue,
+		time.Since(created).Seconds(),
+		jobId,
+	)
+}
+
+// Returns the creation time of the cgroup observed by the lifecycle tracker,
+// falling back to the cgroup directory modification time.
+func (c *CgroupCollector) cgroupCreated(cgroupPath string) (time.Time, error) {
+	if cgroupTracker != nil {
+		if lifecycle, exists := cgroupTracker.Get(cgroupPath); exists {
+			return lifecycle.Created, nil
+		}
+	}
+
+	return cgroups.NewCgroupManager(c.cgroupRoot).Created(cgroupPath)
+}
+
+// PBS suspends jobs with a signal by default; the cgroup is only frozen when
+// the hook or a site suspend script uses the freezer.
+func (c *CgroupCollector) collectFreezer(ch chan<- prometheus.Metric, jobLabels []string, freezer string, jobSuspended bool) {
+	frozen := freezer == cgroups.FreezerFrozen
+	if c.jobCollectorEnabled && frozen != jobSuspended {
+		c.logger.Debug("Cgroup freezer state differs from job state", "jobId", jobLabels[0], "freezer", freezer, "suspended", jobSuspended)
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.metrics.suspendedDesc,
+		prometheus.GaugeValue,
+		float64(utils.BooleanToInt(frozen || jobSuspended)),
+		jobLabels...,
+	)
+
+	if freezer == "" {
+		return
+	}
+	for _, state := range []string{cgroups.FreezerThawed, cgroups.FreezerFreezing, cgroups.FreezerFrozen} {
+		ch <- prometheus.MustNewConstMetric(
+			c.metrics.freezerStateDesc,
+			prometheus.GaugeValue,
+			float64(utils.BooleanToInt(freezer == state)),
+			append(jobLabels, state)...,
+		)
+	}
+}
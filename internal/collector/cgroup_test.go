@@ -1,14 +1,15 @@
 package collector
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
-	"regexp"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
 	"github.com/0nebody/pbs_exporter/internal/pbsjob"
-	"github.com/0nebody/pbs_exporter/internal/utils"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 )
@@ -43,21 +44,74 @@ func TestDescribeCgroups(t *testing.T) {
 }
 
 func TestCollectCgroups(t *testing.T) {
+	root := t.TempDir()
+	mockCgroup(t, root, "/pbs_jobs.service/jobs/1000.pbs", 30, 3072, 3)
+
 	config := configEnabled
 	config.EnableJobCollector = false
-	config.CgroupRoot = "/sys/fs/cgroup"
-	config.CgroupPath = "user.slice"
-	cgroupCollector := NewCgroupCollector(config)
+	config.CgroupRoot = root
+	config.CgroupPath = "pbs_jobs.service/jobs"
 	registry := prometheus.NewRegistry()
-	registry.MustRegister(newCollectorContext(cgroupCollector))
-	utils.PbsJobIdRegex = regexp.MustCompile(`/user.slice/user-(\d+).slice`)
-
-	t.Run("CollectAndCount", func(t *testing.T) {
-		got := testutil.CollectAndCount(registry)
-		// assumes io and hugetlb disabled in test environment
-		want := reflect.TypeOf(*cgroupCollector.metrics).NumField() - 7
-		if got < want {
-			t.Errorf("CollectAndCount() = %d, want %d", got, want)
+	registry.MustRegister(newCollectorContext(NewCgroupCollector(config)))
+
+	t.Run("CollectAndCompare", func(t *testing.T) {
+		want := `# HELP pbs_cgroup_cpu_usage_seconds_total Total CPU time in seconds consumed by tasks in the cgroup.
+# TYPE pbs_cgroup_cpu_usage_seconds_total counter
+pbs_cgroup_cpu_usage_seconds_total{jobid="1000",runcount=""} 30
+# HELP pbs_cgroup_mem_usage_bytes Total memory used by tasks in the cgroup.
+# TYPE pbs_cgroup_mem_usage_bytes gauge
+pbs_cgroup_mem_usage_bytes{jobid="1000",runcount=""} 3072
+# HELP pbs_cgroup_pid_usage Number of PIDs used by the cgroup.
+# TYPE pbs_cgroup_pid_usage gauge
+pbs_cgroup_pid_usage{jobid="1000",runcount=""} 3
+`
+		metrics := []string{
+			"pbs_cgroup_cpu_usage_seconds_total",
+			"pbs_cgroup_mem_usage_bytes",
+			"pbs_cgroup_pid_usage",
+		}
+		if err := testutil.CollectAndCompare(registry, strings.NewReader(want), metrics...); err != nil {
+			t.Errorf("CollectAndCompare() returned error: %v", err)
+		}
+	})
+
+	t.Run("Metric names", func(t *testing.T) {
+		families, err := registry.Gather()
+		if err != nil {
+			t.Fatalf("Gather() returned error: %v", err)
+		}
+		got := make(map[string]bool, len(families))
+		for _, family := range families {
+			got[family.GetName()] = true
+		}
+
+		// metrics of the cpu, memory and pids controllers in every cgroup version
+		want := []string{
+			"pbs_cgroup_cpu_system_seconds_total",
+			"pbs_cgroup_cpu_usage_seconds_total",
+			"pbs_cgroup_cpu_user_seconds_total",
+			"pbs_cgroup_mem_file_mapped_bytes",
+			"pbs_cgroup_mem_limit_bytes",
+			"pbs_cgroup_mem_rss_bytes",
+			"pbs_cgroup_mem_usage_bytes",
+			"pbs_cgroup_pid_limit",
+			"pbs_cgroup_pid_usage",
+			"pbs_cgroup_suspended",
+			"pbs_cgroup_thread_usage",
+		}
+		for _, name := range want {
+			if !got[name] {
+				t.Errorf("Gather() missing %s", name)
+			}
+		}
+
+		// the fixture has no io or hugetlb controller, and its job is not orphaned
+		for name := range got {
+			for _, prefix := range []string{"pbs_cgroup_io_", "pbs_cgroup_hugetlb_", "pbs_cgroup_orphaned"} {
+				if strings.HasPrefix(name, prefix) {
+					t.Errorf("Gather() = %s, want no %s* metrics", name, prefix)
+				}
+			}
 		}
 	})
 
@@ -70,18 +124,159 @@ func TestCollectCgroups(t *testing.T) {
 			t.Errorf("CollectAndLint found issues: %v", lint)
 		}
 	})
+}
 
-	t.Run("CollectNoJobID", func(t *testing.T) {
-		config.EnableJobCollector = true
-		cgroupCollector := NewCgroupCollector(config)
-		jobCache = pbsjob.NewJobCache(cgroupCollector.logger, 60, 15*time.Second)
-		registry := prometheus.NewRegistry()
-		registry.MustRegister(newCollectorContext(cgroupCollector))
-
-		got := testutil.CollectAndCount(registry)
-		want := 0
-		if got != want {
-			t.Errorf("CollectAndCount() = %d, want %d", got, want)
+// job cgroups without a job in the job cache are reported as orphaned only
+func TestCollectOrphanedCgroups(t *testing.T) {
+	root := t.TempDir()
+	mockCgroup(t, root, "/pbs_jobs.service/jobs/1000.pbs", 30, 3072, 3)
+
+	config := configEnabled
+	config.EnableJobCollector = true
+	config.CgroupRoot = root
+	config.CgroupPath = "pbs_jobs.service/jobs"
+	cgroupCollector := NewCgroupCollector(config)
+	original := jobCache
+	jobCache = pbsjob.NewJobCache(cgroupCollector.logger, 60, 15*time.Second)
+	t.Cleanup(func() { jobCache = original })
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newCollectorContext(cgroupCollector))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() returned error: %v", err)
+	}
+	var got []string
+	for _, family := range families {
+		got = append(got, family.GetName())
+	}
+	want := []string{
+		"pbs_cgroup_orphaned",
+		"pbs_cgroup_orphaned_age_seconds",
+		"pbs_cgroup_orphaned_mem_usage_bytes",
+		"pbs_cgroup_orphaned_pids",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Gather() = %v, want %v", got, want)
+	}
+
+	wantMetrics := `# HELP pbs_cgroup_orphaned Job cgroup without a job file; the job has ended or the cgroup was leaked.
+# TYPE pbs_cgroup_orphaned gauge
+pbs_cgroup_orphaned{jobid="1000"} 1
+# HELP pbs_cgroup_orphaned_mem_usage_bytes Total memory used by tasks in the orphaned cgroup.
+# TYPE pbs_cgroup_orphaned_mem_usage_bytes gauge
+pbs_cgroup_orphaned_mem_usage_bytes{jobid="1000"} 3072
+# HELP pbs_cgroup_orphaned_pids Number of processes in the orphaned cgroup.
+# TYPE pbs_cgroup_orphaned_pids gauge
+pbs_cgroup_orphaned_pids{jobid="1000"} 3
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(wantMetrics), want[0], want[2], want[3]); err != nil {
+		t.Errorf("GatherAndCompare() returned error: %v", err)
+	}
+}
+
+func TestCollectOrphaned(t *testing.T) {
+	config := configEnabled
+	config.CgroupRoot = t.TempDir()
+	cgroupCollector := NewCgroupCollector(config)
+	metric := &cgroups.Metrics{
+		Path:   "/pbs_jobs.service/jobs/1000.pbs",
+		Memory: cgroups.Memory{Usage: 1024},
+		Tasks:  cgroups.Tasks{PidUsage: 2},
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		cgroupCollector.collectOrphaned(ch, metric, "1000")
+	}()
+
+	// age is omitted when the cgroup directory no longer exists
+	var got []string
+	for m := range ch {
+		got = append(got, promDescFqname(m.Desc().String()))
+	}
+	want := []string{"pbs_cgroup_orphaned", "pbs_cgroup_orphaned_mem_usage_bytes", "pbs_cgroup_orphaned_pids"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectOrphaned() = %v, want %v", got, want)
+	}
+}
+
+func TestCgroupCreated(t *testing.T) {
+	config := configEnabled
+	config.CgroupRoot = t.TempDir()
+	cgroupCollector := NewCgroupCollector(config)
+	manager := cgroups.NewCgroupManager(config.CgroupRoot)
+	jobDir := filepath.Join(manager.Dir("pbs_jobs.service/jobs"), "1000.pbs")
+	if err := os.MkdirAll(jobDir, 0755); err != nil {
+		t.Fatalf("Failed to create cgroup directory: %v", err)
+	}
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(jobDir, created, created); err != nil {
+		t.Fatalf("Failed to set cgroup directory mtime: %v", err)
+	}
+
+	tracker := cgroups.NewLifecycleTracker(manager, "pbs_jobs.service/jobs", time.Minute, config.Logger)
+	original := cgroupTracker
+	cgroupTracker = tracker
+	t.Cleanup(func() {
+		cgroupTracker = original
+		tracker.Close()
+	})
+
+	// nested cgroups created after the tracker started reset the mtime
+	if err := os.Mkdir(filepath.Join(jobDir, "nested"), 0755); err != nil {
+		t.Fatalf("Failed to create nested cgroup directory: %v", err)
+	}
+
+	got, err := cgroupCollector.cgroupCreated("/pbs_jobs.service/jobs/1000.pbs")
+	if err != nil {
+		t.Fatalf("cgroupCreated() returned error: %v", err)
+	}
+	if !got.Equal(created) {
+		t.Errorf("cgroupCreated() = %v, want %v", got, created)
+	}
+
+	t.Run("Untracked", func(tt *testing.T) {
+		cgroupTracker = nil
+		got, err := cgroupCollector.cgroupCreated("/pbs_jobs.service/jobs/1000.pbs")
+		if err != nil {
+			tt.Fatalf("cgroupCreated() returned error: %v", err)
+		}
+		if got.Equal(created) {
+			tt.Errorf("cgroupCreated() = %v, want directory mtime after nested cgroup was created", got)
 		}
 	})
 }
+
+func TestCollectFreezer(t *testing.T) {
+	cgroupCollector := NewCgroupCollector(configEnabled)
+	tests := []struct {
+		name         string
+		freezer      string
+		jobSuspended bool
+		want         int
+	}{
+		{"Freezer unavailable", "", true, 1},
+		{"Frozen", cgroups.FreezerFrozen, false, 4},
+		{"Thawed", cgroups.FreezerThawed, false, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ch := make(chan prometheus.Metric)
+			go func() {
+				defer close(ch)
+				cgroupCollector.collectFreezer(ch, []string{"1000", "1"}, tt.freezer, tt.jobSuspended)
+			}()
+
+			got := 0
+			for range ch {
+				got++
+			}
+			if got != tt.want {
+				t.Errorf("collectFreezer() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
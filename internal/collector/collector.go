@@ -3,6 +3,7 @@ package collector
 import (
 	"context"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/0nebody/pbs_exporter/internal/cgroups"
@@ -15,8 +16,9 @@ var (
 	defaultNodeLabels = []string{"node", "vnode"}
 	hostname          = utils.MustHostname()
 	pbsCgroupPaths    = map[string]string{
-		"v1": "pbs_jobs.service/jobid",
-		"v2": "pbs_jobs.service/jobs",
+		"hybrid": "pbs_jobs.service/jobid",
+		"v1":     "pbs_jobs.service/jobid",
+		"v2":     "pbs_jobs.service/jobs",
 	}
 )
 
@@ -24,6 +26,7 @@ type Collectors struct {
 	cgroupCollector *CgroupCollector
 	jobCollector    *JobCollector
 	nodeCollector   *NodeCollector
+	rogueCollector  *RogueCollector
 	timeout         time.Duration
 }
 
@@ -31,13 +34,25 @@ type CollectorConfig struct {
 	CgroupPath    string
 	CgroupRoot    string
 	CgroupVersion string
+	GracePeriod   time.Duration
 	Logger        *slog.Logger
+	NestedDepth   int
 	PbsHome       string
+	ProcRoot      string
+	ProcessTopN   int
+	PssInterval   time.Duration
+	RogueMinUid   uint64
 	ScrapeTimeout int
 
 	EnableCgroupCollector bool
+	EnableFileDescriptors bool
+	EnableGpuInfo         bool
 	EnableJobCollector    bool
+	EnableLifecycle       bool
 	EnableNodeCollector   bool
+	EnableProcessStates   bool
+	EnableRogueCollector  bool
+	EnableSliceMetrics    bool
 }
 
 func NewCollectorConfig(cgroupRoot string, logger *slog.Logger) CollectorConfig {
@@ -48,10 +63,28 @@ func NewCollectorConfig(cgroupRoot string, logger *slog.Logger) CollectorConfig
 		CgroupPath:    pbsCgroupPaths[cgroupVersion],
 		CgroupRoot:    cgroupRoot,
 		CgroupVersion: cgroupVersion,
+		GracePeriod:   jobCacheTimeout * time.Second,
 		Logger:        logger,
+		ProcRoot:      "/proc",
 	}
 }
 
+// Overrides the job cgroup path and job ID pattern. The pattern is derived
+// from a custom path when not given.
+func (c *CollectorConfig) SetCgroupLayout(cgroupPath string, jobIdPattern string) error {
+	if cgroupPath != "" {
+		c.CgroupPath = strings.Trim(cgroupPath, "/")
+	}
+	if jobIdPattern == "" && c.CgroupPath != pbsCgroupPaths[c.CgroupVersion] {
+		jobIdPattern = utils.CgroupJobIdPattern(c.CgroupPath)
+	}
+	if jobIdPattern == "" {
+		return nil
+	}
+
+	return utils.SetPbsJobIdRegex(jobIdPattern)
+}
+
 func NewCollectors(config CollectorConfig) *Collectors {
 	collectors := &Collectors{
 		timeout: time.Duration(config.ScrapeTimeout) * time.Second,
@@ -75,6 +108,12 @@ func NewCollectors(config CollectorConfig) *Collectors {
 		config.Logger.Info("PBS Node collector is disabled")
 	}
 
+	if config.EnableRogueCollector {
+		collectors.rogueCollector = NewRogueCollector(config)
+	} else {
+		config.Logger.Info("Rogue process collector is disabled")
+	}
+
 	return collectors
 }
 
@@ -88,6 +127,9 @@ func (c *Collectors) Describe(ch chan<- *prometheus.Desc) {
 	if c.cgroupCollector != nil {
 		c.cgroupCollector.Describe(ch)
 	}
+	if c.rogueCollector != nil {
+		c.rogueCollector.Describe(ch)
+	}
 }
 
 func (c *Collectors) Collect(ch chan<- prometheus.Metric) {
@@ -103,4 +145,7 @@ func (c *Collectors) Collect(ch chan<- prometheus.Metric) {
 	if c.cgroupCollector != nil {
 		c.cgroupCollector.Collect(ctx, ch)
 	}
+	if c.rogueCollector != nil {
+		c.rogueCollector.Collect(ctx, ch)
+	}
 }
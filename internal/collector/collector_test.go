@@ -9,6 +9,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/0nebody/pbs_exporter/internal/utils"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -60,9 +61,11 @@ var configEnabled = CollectorConfig{
 	CgroupRoot:            "./testdata",
 	Logger:                slog.New(slog.NewTextHandler(os.Stderr, nil)),
 	PbsHome:               "./testdata",
+	ProcRoot:              "/proc",
 	EnableCgroupCollector: true,
 	EnableJobCollector:    true,
 	EnableNodeCollector:   true,
+	EnableRogueCollector:  true,
 }
 
 var configDisabled = CollectorConfig{
@@ -70,6 +73,7 @@ var configDisabled = CollectorConfig{
 	EnableCgroupCollector: false,
 	EnableJobCollector:    false,
 	EnableNodeCollector:   false,
+	EnableRogueCollector:  false,
 }
 
 func TestNewCollectors(t *testing.T) {
@@ -111,6 +115,7 @@ func TestDescribe(t *testing.T) {
 		want := reflect.TypeOf(*collectors.cgroupCollector.metrics).NumField()
 		want += reflect.TypeOf(*collectors.jobCollector.metrics).NumField()
 		want += reflect.TypeOf(*collectors.nodeCollector.metrics).NumField()
+		want += reflect.TypeOf(*collectors.rogueCollector.metrics).NumField()
 
 		for desc := range ch {
 			got++
@@ -179,3 +184,40 @@ func TestCollect(t *testing.T) {
 		}
 	})
 }
+
+func TestSetCgroupLayout(t *testing.T) {
+	tests := []struct {
+		name         string
+		cgroupPath   string
+		jobIdPattern string
+		wantPath     string
+		jobPath      string
+		wantJobId    string
+		wantErr      bool
+	}{
+		{"Default layout", "", "", "pbs_jobs.service/jobs", "/pbs_jobs.service/jobs/1000.pbs", "1000", false},
+		{"Custom path", "/pbs.slice/jobs/", "", "pbs.slice/jobs", "/pbs.slice/jobs/1000.2", "1000[1]", false},
+		{"Custom regex", "pbs.slice", `pbs\.slice/job-(\d+)`, "pbs.slice", "/pbs.slice/job-1000", "1000", false},
+		{"Invalid regex", "", `(\d+`, "pbs_jobs.service/jobs", "", "", true},
+	}
+
+	original := utils.PbsJobIdRegex
+	t.Cleanup(func() { utils.PbsJobIdRegex = original })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utils.PbsJobIdRegex = original
+			config := CollectorConfig{CgroupPath: pbsCgroupPaths["v2"], CgroupVersion: "v2"}
+
+			err := config.SetCgroupLayout(tt.cgroupPath, tt.jobIdPattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetCgroupLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if config.CgroupPath != tt.wantPath {
+				t.Errorf("SetCgroupLayout() CgroupPath = %s, want %s", config.CgroupPath, tt.wantPath)
+			}
+			if got := utils.GetCgroupJobId(tt.jobPath); got != tt.wantJobId {
+				t.Errorf("GetCgroupJobId(%s) = %s, want %s", tt.jobPath, got, tt.wantJobId)
+			}
+		})
+	}
+}
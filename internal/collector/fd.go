@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type FdMetrics struct {
+	fdUsageDesc *prometheus.Desc
+}
+
+func NewFdMetrics() *FdMetrics {
+	return &FdMetrics{
+		fdUsageDesc: prometheus.NewDesc(
+			"pbs_cgroup_fd_usage",
+			"Number of file descriptors open by tasks in the cgroup by type.",
+			append(defaultJobLabels, "type"),
+			nil,
+		),
+	}
+}
+
+func (m *FdMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.fdUsageDesc
+}
+
+func (c *CgroupCollector) collectFileDescriptors(ch chan<- prometheus.Metric, jobLabels []string, pids []uint64) {
+	fdTypes, err := c.procFs.FileDescriptors(pids)
+	if err != nil {
+		c.logger.Error("Error reading job file descriptors", "err", err, "jobId", jobLabels[0])
+		return
+	}
+
+	for fdType, count := range fdTypes {
+		ch <- prometheus.MustNewConstMetric(
+			c.fdMetrics.fdUsageDesc,
+			prometheus.GaugeValue,
+			float64(count),
+			append(jobLabels, fdType)...,
+		)
+	}
+}
@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type fdCollector struct {
+	collector *CgroupCollector
+	pids      []uint64
+}
+
+func (c *fdCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.fdMetrics.Describe(ch)
+}
+
+func (c *fdCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collector.collectFileDescriptors(ch, []string{"1000", "1"}, c.pids)
+}
+
+func TestCollectFileDescriptors(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProcFds(t, procRoot, 1000, "/dev/null", "/home/user/job.log", "pipe:[100]", "socket:[200]", "socket:[201]")
+	mockProcFds(t, procRoot, 1001, "anon_inode:[eventfd]", "/dev/nvidia0", "/tmp/scratch")
+
+	config := configEnabled
+	config.ProcRoot = procRoot
+	config.EnableFileDescriptors = true
+	collector := &fdCollector{
+		collector: NewCgroupCollector(config),
+		// exited processes are skipped
+		pids: []uint64{1000, 1001, 1002},
+	}
+
+	want := `# HELP pbs_cgroup_fd_usage Number of file descriptors open by tasks in the cgroup by type.
+# TYPE pbs_cgroup_fd_usage gauge
+pbs_cgroup_fd_usage{jobid="1000",runcount="1",type="anon_inode"} 1
+pbs_cgroup_fd_usage{jobid="1000",runcount="1",type="device"} 2
+pbs_cgroup_fd_usage{jobid="1000",runcount="1",type="file"} 2
+pbs_cgroup_fd_usage{jobid="1000",runcount="1",type="other"} 0
+pbs_cgroup_fd_usage{jobid="1000",runcount="1",type="pipe"} 1
+pbs_cgroup_fd_usage{jobid="1000",runcount="1",type="socket"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+}
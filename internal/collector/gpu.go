@@ -0,0 +1,52 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type GpuMetrics struct {
+	gpuInfoDesc *prometheus.Desc
+}
+
+func NewGpuMetrics() *GpuMetrics {
+	return &GpuMetrics{
+		gpuInfoDesc: prometheus.NewDesc(
+			"pbs_cgroup_gpu_info",
+			"NVIDIA GPU assigned to the cgroup by device minor number.",
+			append(defaultJobLabels, "gpu_minor"),
+			nil,
+		),
+	}
+}
+
+func (m *GpuMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.gpuInfoDesc
+}
+
+// GPUs are read from the v1 device controller. Device rules of v2 are eBPF
+// programs which cannot be read back, and wildcard v1 rules allow every GPU,
+// so when the allowed GPUs are unknown those opened by job processes are used
+// instead.
+func (c *CgroupCollector) collectGpus(ch chan<- prometheus.Metric, jobLabels []string, metric *cgroups.Metrics) {
+	gpus := metric.Gpus
+	if gpus == nil && c.procFs != nil {
+		var err error
+		gpus, err = c.procFs.NvidiaGpus(metric.Tasks.Pids)
+		if err != nil {
+			c.logger.Error("Error reading job GPU devices", "err", err, "jobId", jobLabels[0])
+			return
+		}
+	}
+
+	for _, gpu := range gpus {
+		ch <- prometheus.MustNewConstMetric(
+			c.gpuMetrics.gpuInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			append(jobLabels, strconv.Itoa(gpu))...,
+		)
+	}
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Writes /proc/<pid>/fd as symlinks to targets.
+func mockProcFds(t *testing.T, root string, pid uint64, targets ...string) {
+	t.Helper()
+	fdPath := filepath.Join(root, fmt.Sprint(pid), "fd")
+	if err := os.MkdirAll(fdPath, 0755); err != nil {
+		t.Fatalf("Failed to create fd dir: %v", err)
+	}
+	for i, target := range targets {
+		if err := os.Symlink(target, filepath.Join(fdPath, fmt.Sprint(i))); err != nil {
+			t.Fatalf("Failed to create fd symlink: %v", err)
+		}
+	}
+}
+
+type gpuCollector struct {
+	collector *CgroupCollector
+	metric    *cgroups.Metrics
+}
+
+func (c *gpuCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.gpuMetrics.Describe(ch)
+}
+
+func (c *gpuCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collector.collectGpus(ch, []string{"1000", "1"}, c.metric)
+}
+
+func TestCollectGpus(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProcFds(t, procRoot, 1000, "/dev/nvidiactl", "/dev/nvidia-uvm", "/dev/nvidia2")
+	mockProcFds(t, procRoot, 1001, "/dev/null", "/dev/nvidia1")
+
+	config := configEnabled
+	config.ProcRoot = procRoot
+	config.EnableGpuInfo = true
+	cgroupCollector := NewCgroupCollector(config)
+
+	header := `# HELP pbs_cgroup_gpu_info NVIDIA GPU assigned to the cgroup by device minor number.
+# TYPE pbs_cgroup_gpu_info gauge
+`
+	tests := []struct {
+		name string
+		gpus []int
+		want string
+	}{
+		{
+			name: "Device controller",
+			gpus: []int{0, 3},
+			want: header + `pbs_cgroup_gpu_info{gpu_minor="0",jobid="1000",runcount="1"} 1
+pbs_cgroup_gpu_info{gpu_minor="3",jobid="1000",runcount="1"} 1
+`,
+		},
+		{
+			// processes with GPUs open are not scanned when none are allowed
+			name: "No GPUs",
+			gpus: []int{},
+			want: "",
+		},
+		{
+			name: "Open by processes",
+			gpus: nil,
+			want: header + `pbs_cgroup_gpu_info{gpu_minor="1",jobid="1000",runcount="1"} 1
+pbs_cgroup_gpu_info{gpu_minor="2",jobid="1000",runcount="1"} 1
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := &gpuCollector{
+				collector: cgroupCollector,
+				metric: &cgroups.Metrics{
+					Path:  "/pbs_jobs.service/jobs/1000.pbs",
+					Gpus:  tt.gpus,
+					Tasks: cgroups.Tasks{Pids: []uint64{1000, 1001}},
+				},
+			}
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(tt.want)); err != nil {
+				t.Errorf("CollectAndCompare() returned error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Final cgroup snapshots of ended jobs. A job's cgroup is removed when it
+// ends, so counters accumulated since the last scrape would otherwise be
+// lost; snapshots are kept for the grace period. Jobs which start and end
+// between scrapes are never seen, so have no snapshot.
+type graceCache struct {
+	entries map[string]*graceEntry
+	mu      sync.Mutex
+	timeout time.Duration
+}
+
+type graceEntry struct {
+	jobLabels []string
+	lastSeen  time.Time
+	metrics   *cgroups.Metrics
+}
+
+func newGraceCache(timeout time.Duration) *graceCache {
+	return &graceCache{
+		entries: make(map[string]*graceEntry),
+		timeout: timeout,
+	}
+}
+
+// Stores the latest snapshot of a running job's cgroup.
+func (g *graceCache) set(metrics *cgroups.Metrics, jobLabels []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.entries[metrics.Path] = &graceEntry{
+		jobLabels: jobLabels,
+		lastSeen:  time.Now(),
+		metrics:   metrics,
+	}
+}
+
+// Returns snapshots of cgroups not in seen, removing those older than the timeout.
+func (g *graceCache) ended(seen map[string]bool) []*graceEntry {
+	var entries []*graceEntry
+	now := time.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for path, entry := range g.entries {
+		if seen[path] {
+			continue
+		}
+		if now.Sub(entry.lastSeen) > g.timeout {
+			delete(g.entries, path)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func (c *CgroupCollector) collectEnded(ch chan<- prometheus.Metric, seen map[string]bool) {
+	if c.grace == nil {
+		return
+	}
+	for _, entry := range c.grace.ended(seen) {
+		c.collectCounters(ch, entry.metrics, entry.jobLabels)
+		if c.lifecycleMetrics != nil {
+			c.collectLifecycle(ch, entry.jobLabels, entry.metrics.Path)
+		}
+	}
+}
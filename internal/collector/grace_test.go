@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestGraceCache(t *testing.T) {
+	cache := newGraceCache(time.Minute)
+	cache.set(&cgroups.Metrics{Path: "/1000"}, []string{"1000", "1"})
+	cache.set(&cgroups.Metrics{Path: "/1001"}, []string{"1001", "1"})
+
+	t.Run("Running jobs excluded", func(t *testing.T) {
+		got := cache.ended(map[string]bool{"/1000": true, "/1001": true})
+		if len(got) != 0 {
+			t.Errorf("ended() = %d entries, want 0", len(got))
+		}
+	})
+
+	t.Run("Ended job included", func(t *testing.T) {
+		got := cache.ended(map[string]bool{"/1000": true})
+		if len(got) != 1 || got[0].metrics.Path != "/1001" {
+			t.Errorf("ended() = %+v, want /1001", got)
+		}
+	})
+
+	t.Run("Expired job removed", func(t *testing.T) {
+		cache.entries["/1001"].lastSeen = time.Now().Add(-2 * time.Minute)
+		got := cache.ended(map[string]bool{"/1000": true})
+		if len(got) != 0 {
+			t.Errorf("ended() = %d entries, want 0", len(got))
+		}
+		if _, exists := cache.entries["/1001"]; exists {
+			t.Errorf("ended() did not remove expired entry")
+		}
+	})
+}
+
+func TestCollectEnded(t *testing.T) {
+	config := configEnabled
+	config.GracePeriod = time.Minute
+	cgroupCollector := NewCgroupCollector(config)
+	cgroupCollector.grace.set(&cgroups.Metrics{
+		Path: "/1000",
+		Io:   cgroups.IO{Usage: []cgroups.IoUsage{{Device: "dm-0", Major: 253}}},
+	}, []string{"1000", "1"})
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		cgroupCollector.collectEnded(ch, map[string]bool{})
+	}()
+
+	// cpu system, usage, user, pgfault, pgmajfault and six io counters
+	got := 0
+	want := 11
+	for range ch {
+		got++
+	}
+	if got != want {
+		t.Errorf("collectEnded() = %d, want %d", got, want)
+	}
+}
+
+func TestCollectEndedDisabled(t *testing.T) {
+	cgroupCollector := NewCgroupCollector(configEnabled)
+	if cgroupCollector.grace != nil {
+		t.Fatalf("NewCgroupCollector() grace = %v, want nil without a grace period", cgroupCollector.grace)
+	}
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		cgroupCollector.collectEnded(ch, map[string]bool{})
+	}()
+
+	got := 0
+	for range ch {
+		got++
+	}
+	if got != 0 {
+		t.Errorf("collectEnded() = %d, want 0", got)
+	}
+}
@@ -13,8 +13,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 )
 
+// Seconds ended jobs are kept in the job cache.
+const jobCacheTimeout = 60
+
 var (
 	jobCache   *pbsjob.JobCache
+	jobTracker *pbsjob.JobTracker
 	pbsJobPath = "mom_priv/jobs"
 )
 
@@ -41,12 +45,18 @@ type JobMetrics struct {
 	runCountDesc          *prometheus.Desc
 	startTimeDesc         *prometheus.Desc
 	endTimeDesc           *prometheus.Desc
+	watcherLastSyncDesc   *prometheus.Desc
+	watcherPollingDesc    *prometheus.Desc
+	watcherRestartsDesc   *prometheus.Desc
+	watcherUpDesc         *prometheus.Desc
 }
 
-func InitialiseJobCache(pbsHome string, logger *slog.Logger) error {
-	jobCache = pbsjob.NewJobCache(logger, 60, 15*time.Second)
+func InitialiseJobCache(pbsHome string, pollInterval time.Duration, logger *slog.Logger) error {
+	jobCache = pbsjob.NewJobCache(logger, jobCacheTimeout, 15*time.Second)
 
+	// the tracker polls until the job directory can be watched
 	jobPath := filepath.Join(pbsHome, pbsJobPath)
+	jobTracker = pbsjob.NewJobTracker(jobPath, jobCache, pollInterval, logger)
 	if !utils.DirectoryExists(jobPath) {
 		return fmt.Errorf("job directory does not exist: %s", jobPath)
 	}
@@ -66,19 +76,14 @@ func InitialiseJobCache(pbsHome string, logger *slog.Logger) error {
 	return nil
 }
 
-func WatchPbsJobs(pbsHome string, logger *slog.Logger) error {
-	watchPath := filepath.Join(pbsHome, pbsJobPath)
-	if !utils.DirectoryExists(watchPath) {
-		return fmt.Errorf("PBS job directory does not exist: %s", watchPath)
+// Tracks job files until the exporter exits; the job watcher is restarted
+// whenever it stops.
+func WatchPbsJobs() error {
+	if jobTracker == nil {
+		return fmt.Errorf("job tracker is uninitialised")
 	}
 
-	watcher, err := pbsjob.NewJobWatcher(watchPath)
-	if err != nil {
-		return fmt.Errorf("failed creating job watcher: %w", err)
-	}
-	defer watcher.Close()
-
-	if err := pbsjob.PbsJobEvent(watcher, logger, jobCache); err != nil {
+	if err := jobTracker.Watch(context.Background()); err != nil {
 		return fmt.Errorf("failed to watch PBS jobs: %w", err)
 	}
 
@@ -186,6 +191,30 @@ func NewJobCollector(config CollectorConfig) *JobCollector {
 			defaultJobLabels,
 			nil,
 		),
+		watcherLastSyncDesc: prometheus.NewDesc(
+			"pbs_job_watcher_last_sync_timestamp_seconds",
+			"Time the job cache was last synced with the job directory in seconds since the epoch.",
+			nil,
+			nil,
+		),
+		watcherPollingDesc: prometheus.NewDesc(
+			"pbs_job_watcher_polling",
+			"Whether job files are polled while the job watcher is down.",
+			nil,
+			nil,
+		),
+		watcherRestartsDesc: prometheus.NewDesc(
+			"pbs_job_watcher_restarts_total",
+			"Number of times the job watcher has been restarted.",
+			nil,
+			nil,
+		),
+		watcherUpDesc: prometheus.NewDesc(
+			"pbs_job_watcher_up",
+			"Whether job files are watched for changes.",
+			nil,
+			nil,
+		),
 	}
 
 	return &JobCollector{
@@ -212,6 +241,41 @@ func (j *JobCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- j.metrics.runCountDesc
 	ch <- j.metrics.startTimeDesc
 	ch <- j.metrics.endTimeDesc
+	ch <- j.metrics.watcherLastSyncDesc
+	ch <- j.metrics.watcherPollingDesc
+	ch <- j.metrics.watcherRestartsDesc
+	ch <- j.metrics.watcherUpDesc
+}
+
+// Job metrics are stale while the watcher is down and polling has not synced.
+func (j *JobCollector) collectWatcher(ch chan<- prometheus.Metric) {
+	if jobTracker == nil {
+		return
+	}
+	health := jobTracker.Health()
+
+	if !health.LastSync.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			j.metrics.watcherLastSyncDesc,
+			prometheus.GaugeValue,
+			float64(health.LastSync.UnixNano())/1e9,
+		)
+	}
+	ch <- prometheus.MustNewConstMetric(
+		j.metrics.watcherPollingDesc,
+		prometheus.GaugeValue,
+		float64(utils.BooleanToInt(health.Polling)),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		j.metrics.watcherRestartsDesc,
+		prometheus.CounterValue,
+		float64(health.Restarts),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		j.metrics.watcherUpDesc,
+		prometheus.GaugeValue,
+		float64(utils.BooleanToInt(health.Up)),
+	)
 }
 
 func (j *JobCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) {
@@ -219,6 +283,7 @@ func (j *JobCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric)
 		j.logger.Error("Job cache is uninitialised")
 		return
 	}
+	j.collectWatcher(ch)
 
 	for _, job := range jobCache.List() {
 		jobId := job.JobId()
@@ -268,7 +333,8 @@ func (j *JobCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric)
 			continue
 		}
 
-		// export metrics regardless of user ID, ngpus, and node select
+		// export metrics regardless of user ID, ngpus, and node select.
+		// requested resources the job never asked for are omitted.
 		jobUserId, err := job.JobUid()
 		if err != nil {
 			j.logger.Warn("Error getting job user ID", "jobid", jobId, "error", err)
@@ -316,42 +382,53 @@ func (j *JobCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric)
 			float64(utils.BooleanToInt(job.IsInteractive())),
 			jobLabels...,
 		)
-		ch <- prometheus.MustNewConstMetric(
-			j.metrics.requestedMemoryDesc,
-			prometheus.GaugeValue,
-			float64(job.ResourceList.Mem),
-			jobLabels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			j.metrics.requestedNcpusDesc,
-			prometheus.GaugeValue,
-			float64(job.ResourceList.Ncpus),
-			jobLabels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			j.metrics.requestedNfpgasDesc,
-			prometheus.GaugeValue,
-			float64(job.ResourceList.Nfpgas),
-			jobLabels...,
-		)
-		ch <- prometheus.MustNewConstMetric(
-			j.metrics.requestedNgpusDesc,
-			prometheus.GaugeValue,
-			float64(nGpus),
-			jobLabels...,
-		)
+		if job.IsSet("Resource_List", "mem") {
+			ch <- prometheus.MustNewConstMetric(
+				j.metrics.requestedMemoryDesc,
+				prometheus.GaugeValue,
+				float64(job.ResourceList.Mem),
+				jobLabels...,
+			)
+		}
+		if job.IsSet("Resource_List", "ncpus") {
+			ch <- prometheus.MustNewConstMetric(
+				j.metrics.requestedNcpusDesc,
+				prometheus.GaugeValue,
+				float64(job.ResourceList.Ncpus),
+				jobLabels...,
+			)
+		}
+		if job.IsSet("Resource_List", "nfpgas") {
+			ch <- prometheus.MustNewConstMetric(
+				j.metrics.requestedNfpgasDesc,
+				prometheus.GaugeValue,
+				float64(job.ResourceList.Nfpgas),
+				jobLabels...,
+			)
+		}
+		// ngpus may only be requested in the select statement
+		if job.IsSet("Resource_List", "ngpus") || nGpus > 0 {
+			ch <- prometheus.MustNewConstMetric(
+				j.metrics.requestedNgpusDesc,
+				prometheus.GaugeValue,
+				float64(nGpus),
+				jobLabels...,
+			)
+		}
 		ch <- prometheus.MustNewConstMetric(
 			j.metrics.requestedNodesDesc,
 			prometheus.GaugeValue,
 			float64(nodeSelect),
 			jobLabels...,
 		)
-		ch <- prometheus.MustNewConstMetric(
-			j.metrics.requestedWalltimeDesc,
-			prometheus.GaugeValue,
-			float64(job.RequestedWalltime()),
-			jobLabels...,
-		)
+		if job.IsSet("Resource_List", "walltime") {
+			ch <- prometheus.MustNewConstMetric(
+				j.metrics.requestedWalltimeDesc,
+				prometheus.GaugeValue,
+				float64(job.RequestedWalltime()),
+				jobLabels...,
+			)
+		}
 		ch <- prometheus.MustNewConstMetric(
 			j.metrics.requestsDesc,
 			prometheus.GaugeValue,
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"context"
 	"reflect"
 	"strings"
 	"testing"
@@ -40,6 +41,26 @@ func TestDescribeJobs(t *testing.T) {
 	}
 }
 
+// Starts a job tracker on an empty job directory, waiting for the watcher.
+func startJobTracker(t *testing.T) {
+	t.Helper()
+	jobTracker = pbsjob.NewJobTracker(t.TempDir(), jobCache, time.Second, configEnabled.Logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	go jobTracker.Watch(ctx)
+	t.Cleanup(func() {
+		cancel()
+		jobTracker = nil
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for !jobTracker.Health().Up {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the job watcher")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
 func TestCollectJobs(t *testing.T) {
 	hostname = "cpu1n001"
 	jobCollector := NewJobCollector(configEnabled)
@@ -68,13 +89,20 @@ func TestCollectJobs(t *testing.T) {
 		Project:     "project",
 		RunVersion:  "1",
 		Stime:       time.Now().Unix(),
+		Flags: map[pbsjob.JobMapKey]int32{
+			{Name: "Resource_List", Resource: "mem"}:      pbsjob.JobAttrFlagSet,
+			{Name: "Resource_List", Resource: "ncpus"}:    pbsjob.JobAttrFlagSet,
+			{Name: "Resource_List", Resource: "nfpgas"}:   pbsjob.JobAttrFlagSet,
+			{Name: "Resource_List", Resource: "ngpus"}:    pbsjob.JobAttrFlagSet,
+			{Name: "Resource_List", Resource: "walltime"}: pbsjob.JobAttrFlagSet,
+		},
 	})
 	registry := prometheus.NewRegistry()
 	registry.MustRegister(newCollectorContext(jobCollector))
 
 	got := testutil.CollectAndCount(registry)
-	// assume job isn't running
-	want := reflect.TypeOf(*jobCollector.metrics).NumField() - 1
+	// assume job isn't running, and watcher metrics without a job tracker
+	want := reflect.TypeOf(*jobCollector.metrics).NumField() - 1 - 4
 	if got != want {
 		t.Errorf("CollectAndCount() = %d, want %d", got, want)
 	}
@@ -87,3 +115,74 @@ func TestCollectJobs(t *testing.T) {
 		t.Errorf("CollectAndLint found issues: %v", lint)
 	}
 }
+
+func TestCollectJobsUnrequested(t *testing.T) {
+	hostname = "cpu1n001"
+	jobCollector := NewJobCollector(configEnabled)
+	jobCache = pbsjob.NewJobCache(jobCollector.logger, 60, 15*time.Second)
+	jobCache.Set("1000", &pbsjob.Job{
+		ExecHost: "cpu1n001",
+		Hashname: "1000.pbs",
+		JobState: "R",
+		ResourceList: pbsjob.ResourceList{
+			Ncpus:    4,
+			Walltime: "01:00:00",
+		},
+		SchedSelect: "1:ncpus=4",
+		ExecVnode:   "(cpu1n001[0]:ncpus=4)",
+		RunCount:    1,
+		Stime:       time.Now().Unix(),
+		Flags: map[pbsjob.JobMapKey]int32{
+			{Name: "Resource_List", Resource: "ncpus"}:    pbsjob.JobAttrFlagSet,
+			{Name: "Resource_List", Resource: "walltime"}: pbsjob.JobAttrFlagSet,
+		},
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newCollectorContext(jobCollector))
+
+	tests := []struct {
+		metric string
+		want   int
+	}{
+		{"pbs_job_requested_memory", 0},
+		{"pbs_job_requested_ncpus", 1},
+		{"pbs_job_requested_nfpgas", 0},
+		{"pbs_job_requested_ngpus", 0},
+		{"pbs_job_requested_walltime", 1},
+	}
+	for _, test := range tests {
+		if got := testutil.CollectAndCount(registry, test.metric); got != test.want {
+			t.Errorf("CollectAndCount(%s) = %d, want %d", test.metric, got, test.want)
+		}
+	}
+}
+
+func TestCollectJobWatcher(t *testing.T) {
+	jobCollector := NewJobCollector(configEnabled)
+	jobCache = pbsjob.NewJobCache(jobCollector.logger, 60, 15*time.Second)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newCollectorContext(jobCollector))
+
+	if got := testutil.CollectAndCount(registry, "pbs_job_watcher_up"); got != 0 {
+		t.Errorf("CollectAndCount(pbs_job_watcher_up) = %d without job tracker, want 0", got)
+	}
+
+	startJobTracker(t)
+	want := `
+# HELP pbs_job_watcher_polling Whether job files are polled while the job watcher is down.
+# TYPE pbs_job_watcher_polling gauge
+pbs_job_watcher_polling 0
+# HELP pbs_job_watcher_restarts_total Number of times the job watcher has been restarted.
+# TYPE pbs_job_watcher_restarts_total counter
+pbs_job_watcher_restarts_total 0
+# HELP pbs_job_watcher_up Whether job files are watched for changes.
+# TYPE pbs_job_watcher_up gauge
+pbs_job_watcher_up 1
+`
+	if err := testutil.CollectAndCompare(registry, strings.NewReader(want), "pbs_job_watcher_polling", "pbs_job_watcher_restarts_total", "pbs_job_watcher_up"); err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+	if got := testutil.CollectAndCount(registry, "pbs_job_watcher_last_sync_timestamp_seconds"); got != 1 {
+		t.Errorf("CollectAndCount(pbs_job_watcher_last_sync_timestamp_seconds) = %d, want 1", got)
+	}
+}
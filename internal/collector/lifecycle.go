@@ -0,0 +1,136 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/0nebody/pbs_exporter/internal/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	cgroupTracker *cgroups.LifecycleTracker
+)
+
+type LifecycleMetrics struct {
+	createdDesc         *prometheus.Desc
+	populatedDesc       *prometheus.Desc
+	removedDesc         *prometheus.Desc
+	watcherRestartsDesc *prometheus.Desc
+	watcherUpDesc       *prometheus.Desc
+}
+
+func NewLifecycleMetrics() *LifecycleMetrics {
+	return &LifecycleMetrics{
+		createdDesc: prometheus.NewDesc(
+			"pbs_cgroup_created_timestamp",
+			"Time the cgroup was created in seconds since the epoch.",
+			defaultJobLabels,
+			nil,
+		),
+		populatedDesc: prometheus.NewDesc(
+			"pbs_cgroup_populated",
+			"Whether the cgroup or its descendants contain processes.",
+			defaultJobLabels,
+			nil,
+		),
+		removedDesc: prometheus.NewDesc(
+			"pbs_cgroup_removed_timestamp",
+			"Time the cgroup was removed in seconds since the epoch.",
+			defaultJobLabels,
+			nil,
+		),
+		watcherRestartsDesc: prometheus.NewDesc(
+			"pbs_cgroup_lifecycle_watcher_restarts_total",
+			"Number of times the cgroup lifecycle watcher has been restarted.",
+			nil,
+			nil,
+		),
+		watcherUpDesc: prometheus.NewDesc(
+			"pbs_cgroup_lifecycle_watcher_up",
+			"Whether job cgroups are watched for lifecycle changes.",
+			nil,
+			nil,
+		),
+	}
+}
+
+func (m *LifecycleMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.createdDesc
+	ch <- m.populatedDesc
+	ch <- m.removedDesc
+	ch <- m.watcherRestartsDesc
+	ch <- m.watcherUpDesc
+}
+
+func InitialiseCgroupTracker(config CollectorConfig) {
+	manager := cgroups.NewCgroupManager(config.CgroupRoot)
+	cgroupTracker = cgroups.NewLifecycleTracker(manager, config.CgroupPath, jobCacheTimeout*time.Second, config.Logger)
+}
+
+func WatchCgroups() error {
+	if cgroupTracker == nil {
+		return fmt.Errorf("cgroup tracker is uninitialised")
+	}
+	defer cgroupTracker.Close()
+
+	if err := cgroupTracker.Watch(context.Background()); err != nil {
+		return fmt.Errorf("failed to watch cgroups: %w", err)
+	}
+
+	return nil
+}
+
+// Lifecycle metrics are stale while the watcher is down.
+func (c *CgroupCollector) collectLifecycleWatcher(ch chan<- prometheus.Metric) {
+	if cgroupTracker == nil {
+		return
+	}
+	health := cgroupTracker.Health()
+
+	ch <- prometheus.MustNewConstMetric(
+		c.lifecycleMetrics.watcherRestartsDesc,
+		prometheus.CounterValue,
+		float64(health.Restarts),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.lifecycleMetrics.watcherUpDesc,
+		prometheus.GaugeValue,
+		float64(utils.BooleanToInt(health.Up)),
+	)
+}
+
+func (c *CgroupCollector) collectLifecycle(ch chan<- prometheus.Metric, jobLabels []string, cgroupPath string) {
+	if cgroupTracker == nil {
+		return
+	}
+	lifecycle, exists := cgroupTracker.Get(cgroupPath)
+	if !exists {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.lifecycleMetrics.createdDesc,
+		prometheus.GaugeValue,
+		float64(lifecycle.Created.UnixNano())/1e9,
+		jobLabels...,
+	)
+	if lifecycle.HasEvents {
+		ch <- prometheus.MustNewConstMetric(
+			c.lifecycleMetrics.populatedDesc,
+			prometheus.GaugeValue,
+			float64(utils.BooleanToInt(lifecycle.Populated)),
+			jobLabels...,
+		)
+	}
+	if !lifecycle.Removed.IsZero() {
+		ch <- prometheus.MustNewConstMetric(
+			c.lifecycleMetrics.removedDesc,
+			prometheus.GaugeValue,
+			float64(lifecycle.Removed.UnixNano())/1e9,
+			jobLabels...,
+		)
+	}
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectLifecycle(t *testing.T) {
+	manager := cgroups.NewCgroupManager(t.TempDir())
+	jobPath := filepath.Join(manager.Dir("pbs_jobs.service/jobs"), "1000.pbs")
+	if err := os.MkdirAll(jobPath, 0755); err != nil {
+		t.Fatalf("Failed to create cgroup directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(jobPath, "cgroup.events"), []byte("populated 1\nfrozen 0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write cgroup.events: %v", err)
+	}
+
+	tracker := cgroups.NewLifecycleTracker(manager, "pbs_jobs.service/jobs", time.Minute, configEnabled.Logger)
+	original := cgroupTracker
+	cgroupTracker = tracker
+	t.Cleanup(func() {
+		cgroupTracker = original
+		tracker.Close()
+	})
+
+	config := configEnabled
+	config.EnableLifecycle = true
+	cgroupCollector := NewCgroupCollector(config)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		cgroupCollector.collectLifecycle(ch, []string{"1000", "1"}, "/pbs_jobs.service/jobs/1000.pbs")
+		cgroupCollector.collectLifecycle(ch, []string{"1001", "1"}, "/pbs_jobs.service/jobs/1001.pbs")
+	}()
+
+	// created and populated of the tracked cgroup only
+	got := 0
+	want := 2
+	for range ch {
+		got++
+	}
+	if got != want {
+		t.Errorf("collectLifecycle() = %d, want %d", got, want)
+	}
+}
+
+func TestCollectLifecycleWatcher(t *testing.T) {
+	manager := cgroups.NewCgroupManager(t.TempDir())
+	if err := os.MkdirAll(manager.Dir("pbs_jobs.service/jobs"), 0755); err != nil {
+		t.Fatalf("Failed to create cgroup directory: %v", err)
+	}
+
+	tracker := cgroups.NewLifecycleTracker(manager, "pbs_jobs.service/jobs", time.Minute, configEnabled.Logger)
+	original := cgroupTracker
+	cgroupTracker = tracker
+	t.Cleanup(func() {
+		cgroupTracker = original
+		tracker.Close()
+	})
+
+	config := configEnabled
+	config.EnableLifecycle = true
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newCollectorContext(NewCgroupCollector(config)))
+
+	want := `# HELP pbs_cgroup_lifecycle_watcher_restarts_total Number of times the cgroup lifecycle watcher has been restarted.
+# TYPE pbs_cgroup_lifecycle_watcher_restarts_total counter
+pbs_cgroup_lifecycle_watcher_restarts_total 0
+# HELP pbs_cgroup_lifecycle_watcher_up Whether job cgroups are watched for lifecycle changes.
+# TYPE pbs_cgroup_lifecycle_watcher_up gauge
+pbs_cgroup_lifecycle_watcher_up 1
+`
+	metrics := []string{"pbs_cgroup_lifecycle_watcher_restarts_total", "pbs_cgroup_lifecycle_watcher_up"}
+	if err := testutil.CollectAndCompare(registry, strings.NewReader(want), metrics...); err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+
+	tracker.Close()
+	want = strings.Replace(want, "pbs_cgroup_lifecycle_watcher_up 1", "pbs_cgroup_lifecycle_watcher_up 0", 1)
+	if err := testutil.CollectAndCompare(registry, strings.NewReader(want), metrics...); err != nil {
+		t.Errorf("CollectAndCompare() after close returned error: %v", err)
+	}
+}
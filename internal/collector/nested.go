@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Usage of cgroups below the job cgroup, such as per-rank or container
+// cgroups. Job cgroup metrics already include the usage of their children.
+type NestedMetrics struct {
+	cpuSystemDesc *prometheus.Desc
+	cpuUsageDesc  *prometheus.Desc
+	cpuUserDesc   *prometheus.Desc
+	memRssDesc    *prometheus.Desc
+	memUsageDesc  *prometheus.Desc
+	pidUsageDesc  *prometheus.Desc
+}
+
+func NewNestedMetrics() *NestedMetrics {
+	nestedJobLabels := append(defaultJobLabels, "subpath")
+
+	return &NestedMetrics{
+		cpuSystemDesc: prometheus.NewDesc(
+			"pbs_cgroup_nested_cpu_system_seconds_total",
+			"Total system CPU time in seconds consumed by tasks in the nested cgroup.",
+			nestedJobLabels,
+			nil,
+		),
+		cpuUsageDesc: prometheus.NewDesc(
+			"pbs_cgroup_nested_cpu_usage_seconds_total",
+			"Total CPU time in seconds consumed by tasks in the nested cgroup.",
+			nestedJobLabels,
+			nil,
+		),
+		cpuUserDesc: prometheus.NewDesc(
+			"pbs_cgroup_nested_cpu_user_seconds_total",
+			"Total user CPU time in seconds consumed by tasks in the nested cgroup.",
+			nestedJobLabels,
+			nil,
+		),
+		memRssDesc: prometheus.NewDesc(
+			"pbs_cgroup_nested_mem_rss_bytes",
+			"Resident Set Size (RSS): memory required to run tasks in the nested cgroup.",
+			nestedJobLabels,
+			nil,
+		),
+		memUsageDesc: prometheus.NewDesc(
+			"pbs_cgroup_nested_mem_usage_bytes",
+			"Total memory used by tasks in the nested cgroup.",
+			nestedJobLabels,
+			nil,
+		),
+		pidUsageDesc: prometheus.NewDesc(
+			"pbs_cgroup_nested_pids",
+			"Number of processes in the nested cgroup.",
+			nestedJobLabels,
+			nil,
+		),
+	}
+}
+
+func (m *NestedMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.cpuSystemDesc
+	ch <- m.cpuUsageDesc
+	ch <- m.cpuUserDesc
+	ch <- m.memRssDesc
+	ch <- m.memUsageDesc
+	ch <- m.pidUsageDesc
+}
+
+func (c *CgroupCollector) collectNested(ch chan<- prometheus.Metric, jobLabels []string, jobPath string) {
+	manager := cgroups.NewCgroupManager(c.cgroupRoot)
+	nestedPaths, err := cgroups.ListNested(manager, jobPath, c.nestedDepth)
+	if err != nil {
+		c.logger.Error("Error listing nested cgroups", "err", err, "jobId", jobLabels[0])
+		return
+	}
+
+	for _, nestedPath := range nestedPaths {
+		// nested cgroups may be removed while walking
+		cgroup, err := manager.Load(nestedPath)
+		if err != nil {
+			c.logger.Debug("Error loading nested cgroup", "err", err, "cgroupPath", nestedPath)
+			continue
+		}
+		metric, err := cgroup.Stat()
+		if err != nil {
+			c.logger.Debug("Error getting nested cgroup stats", "err", err, "cgroupPath", nestedPath)
+			continue
+		}
+
+		nestedLabels := append(jobLabels, strings.TrimPrefix(nestedPath, jobPath+"/"))
+		ch <- prometheus.MustNewConstMetric(
+			c.nestedMetrics.cpuSystemDesc,
+			prometheus.CounterValue,
+			float64(metric.Cpu.System),
+			nestedLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.nestedMetrics.cpuUsageDesc,
+			prometheus.CounterValue,
+			float64(metric.Cpu.Usage),
+			nestedLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.nestedMetrics.cpuUserDesc,
+			prometheus.CounterValue,
+			float64(metric.Cpu.User),
+			nestedLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.nestedMetrics.memRssDesc,
+			prometheus.GaugeValue,
+			float64(metric.Memory.Rss),
+			nestedLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.nestedMetrics.memUsageDesc,
+			prometheus.GaugeValue,
+			float64(metric.Memory.Usage),
+			nestedLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.nestedMetrics.pidUsageDesc,
+			prometheus.GaugeValue,
+			float64(metric.Tasks.PidUsage),
+			nestedLabels...,
+		)
+	}
+}
@@ -0,0 +1,174 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Writes a cgroup with the usage files read by the collector, in both the v1
+// hierarchies and the v2 layout so it loads whatever the host cgroup mode.
+func mockCgroup(t *testing.T, root string, cgroupPath string, cpuSeconds uint64, memory uint64, pids uint64) {
+	t.Helper()
+	var procs strings.Builder
+	for i := range pids {
+		fmt.Fprintf(&procs, "%d\n", 1000+i)
+	}
+	files := map[string]string{
+		// v1
+		"cpu,cpuacct/cgroup.procs":         procs.String(),
+		"cpu,cpuacct/cpu.stat":             "nr_periods 0\nnr_throttled 0\nthrottled_time 0\n",
+		"cpu,cpuacct/cpuacct.stat":         "user 0\nsystem 0\n",
+		"cpu,cpuacct/cpuacct.usage":        fmt.Sprintf("%d\n", cpuSeconds*1e9),
+		"cpu,cpuacct/cpuacct.usage_percpu": fmt.Sprintf("%d\n", cpuSeconds*1e9),
+		"memory/cgroup.procs":              procs.String(),
+		"memory/memory.stat":               "total_rss 0\n",
+		"memory/memory.oom_control":        "oom_kill_disable 0\nunder_oom 0\noom_kill 0\n",
+		"pids/cgroup.procs":                procs.String(),
+		"pids/pids.current":                fmt.Sprintf("%d\n", pids),
+		"pids/pids.max":                    "max\n",
+		// v2
+		"cgroup.controllers": "cpu memory pids\n",
+		"cgroup.procs":       procs.String(),
+		"cpu.stat":           fmt.Sprintf("usage_usec %d\nuser_usec 0\nsystem_usec 0\n", cpuSeconds*1e6),
+		"memory.current":     fmt.Sprintf("%d\n", memory),
+		"memory.stat":        "anon 0\nfile_mapped 0\n",
+		"pids.current":       fmt.Sprintf("%d\n", pids),
+		"pids.max":           "max\n",
+	}
+
+	for _, module := range []string{"memory", "memory.memsw", "memory.kmem", "memory.kmem.tcp"} {
+		usage := memory
+		if module != "memory" {
+			usage = 0
+		}
+		files["memory/"+module+".usage_in_bytes"] = fmt.Sprintf("%d\n", usage)
+		files["memory/"+module+".max_usage_in_bytes"] = fmt.Sprintf("%d\n", usage)
+		files["memory/"+module+".failcnt"] = "0\n"
+		files["memory/"+module+".limit_in_bytes"] = "0\n"
+	}
+
+	for name, content := range files {
+		dir, file := filepath.Split(name)
+		path := filepath.Join(root, dir, cgroupPath, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create cgroup directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write cgroup file: %v", err)
+		}
+	}
+	for _, link := range []string{"cpu", "cpuacct"} {
+		if _, err := os.Lstat(filepath.Join(root, link)); err == nil {
+			continue
+		}
+		if err := os.Symlink("cpu,cpuacct", filepath.Join(root, link)); err != nil {
+			t.Fatalf("Failed to link cgroup hierarchy: %v", err)
+		}
+	}
+}
+
+func TestNestedMetricsDescribe(t *testing.T) {
+	config := configEnabled
+	config.NestedDepth = 1
+	cgroupCollector := NewCgroupCollector(config)
+
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		defer close(ch)
+		cgroupCollector.nestedMetrics.Describe(ch)
+	}()
+
+	got := 0
+	want := reflect.TypeOf(NestedMetrics{}).NumField()
+	for range ch {
+		got++
+	}
+	if got != want {
+		t.Errorf("Describe() = %d, want %d", got, want)
+	}
+}
+
+func TestCollectNestedMissingJob(t *testing.T) {
+	config := configEnabled
+	config.CgroupRoot = t.TempDir()
+	config.NestedDepth = 1
+	cgroupCollector := NewCgroupCollector(config)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		cgroupCollector.collectNested(ch, []string{"1000", "1"}, "/jobs/1000.pbs")
+	}()
+
+	got := 0
+	for range ch {
+		got++
+	}
+	if got != 0 {
+		t.Errorf("collectNested() = %d, want 0", got)
+	}
+}
+
+func TestCollectNested(t *testing.T) {
+	root := t.TempDir()
+	jobPath := "/pbs_jobs.service/jobs/1000.pbs"
+	mockCgroup(t, root, jobPath, 30, 3072, 3)
+	mockCgroup(t, root, jobPath+"/rank0", 20, 2048, 2)
+	mockCgroup(t, root, jobPath+"/rank0/thread0", 10, 1024, 1)
+
+	header := `# HELP pbs_cgroup_nested_cpu_usage_seconds_total Total CPU time in seconds consumed by tasks in the nested cgroup.
+# TYPE pbs_cgroup_nested_cpu_usage_seconds_total counter
+`
+	rank := `pbs_cgroup_nested_cpu_usage_seconds_total{jobid="1000",runcount="1",subpath="rank0"} 20
+`
+	thread := `pbs_cgroup_nested_cpu_usage_seconds_total{jobid="1000",runcount="1",subpath="rank0/thread0"} 10
+`
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{1, header + rank},
+		{2, header + rank + thread},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("Depth %d", tt.depth), func(t *testing.T) {
+			config := configEnabled
+			config.CgroupRoot = root
+			config.NestedDepth = tt.depth
+			collector := &nestedCollector{
+				collector: NewCgroupCollector(config),
+				jobLabels: []string{"1000", "1"},
+				jobPath:   jobPath,
+			}
+
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(collector)
+			if err := testutil.CollectAndCompare(registry, strings.NewReader(tt.want), "pbs_cgroup_nested_cpu_usage_seconds_total"); err != nil {
+				t.Errorf("CollectAndCompare() returned error: %v", err)
+			}
+		})
+	}
+}
+
+// nestedCollector collects nested metrics of a single job cgroup.
+type nestedCollector struct {
+	collector *CgroupCollector
+	jobLabels []string
+	jobPath   string
+}
+
+func (c *nestedCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.nestedMetrics.Describe(ch)
+}
+
+func (c *nestedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collector.collectNested(ch, c.jobLabels, c.jobPath)
+}
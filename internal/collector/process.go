@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"strconv"
+
+	"github.com/0nebody/pbs_exporter/internal/process"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type ProcessMetrics struct {
+	cpuDesc *prometheus.Desc
+	rssDesc *prometheus.Desc
+}
+
+func NewProcessMetrics() *ProcessMetrics {
+	processJobLabels := append(defaultJobLabels, "pid", "comm")
+
+	return &ProcessMetrics{
+		cpuDesc: prometheus.NewDesc(
+			"pbs_cgroup_process_cpu_seconds_total",
+			"Total user and system CPU time in seconds consumed by the process.",
+			processJobLabels,
+			nil,
+		),
+		rssDesc: prometheus.NewDesc(
+			"pbs_cgroup_process_rss_bytes",
+			"Resident Set Size (RSS) of the process.",
+			processJobLabels,
+			nil,
+		),
+	}
+}
+
+func (m *ProcessMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.cpuDesc
+	ch <- m.rssDesc
+}
+
+// Exports the top processes of a job by CPU time and RSS.
+func (c *CgroupCollector) collectProcesses(ch chan<- prometheus.Metric, jobLabels []string, pids []uint64) {
+	processes, err := c.procFs.Processes(pids)
+	if err != nil {
+		c.logger.Error("Error reading job processes", "err", err, "jobId", jobLabels[0])
+		return
+	}
+
+	for _, proc := range process.TopProcesses(processes, c.processTopN) {
+		processLabels := append(jobLabels, strconv.FormatUint(proc.Pid, 10), proc.Comm)
+		ch <- prometheus.MustNewConstMetric(
+			c.processMetrics.cpuDesc,
+			prometheus.CounterValue,
+			proc.Cpu,
+			processLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.processMetrics.rssDesc,
+			prometheus.GaugeValue,
+			float64(proc.Rss),
+			processLabels...,
+		)
+	}
+}
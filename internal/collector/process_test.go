@@ -0,0 +1,83 @@
+package collector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDescribeProcesses(t *testing.T) {
+	config := configEnabled
+	config.ProcRoot = t.TempDir()
+	config.ProcessTopN = 1
+	cgroupCollector := NewCgroupCollector(config)
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		defer close(ch)
+		cgroupCollector.Describe(ch)
+	}()
+
+	got := 0
+	want := reflect.TypeOf(*cgroupCollector.metrics).NumField()
+	want += reflect.TypeOf(*cgroupCollector.processMetrics).NumField()
+	for range ch {
+		got++
+	}
+	if got != want {
+		t.Errorf("Describe() = %d, want %d", got, want)
+	}
+}
+
+type processCollector struct {
+	collector *CgroupCollector
+	pids      []uint64
+}
+
+func (c *processCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.processMetrics.Describe(ch)
+}
+
+func (c *processCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collector.collectProcesses(ch, []string{"1000", "1"}, c.pids)
+}
+
+func TestCollectProcesses(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProc(t, procRoot, 1000, "bash", "S", 1, 4)
+	mockProc(t, procRoot, 1001, "python", "R", 500, 1024)
+	mockProc(t, procRoot, 1002, "java", "S", 100, 8192)
+
+	config := configEnabled
+	config.ProcRoot = procRoot
+	config.ProcessTopN = 1
+	collector := &processCollector{
+		collector: NewCgroupCollector(config),
+		// exited processes are skipped
+		pids: []uint64{1000, 1001, 1002, 1003},
+	}
+
+	// top process by CPU time and top process by RSS
+	want := `# HELP pbs_cgroup_process_cpu_seconds_total Total user and system CPU time in seconds consumed by the process.
+# TYPE pbs_cgroup_process_cpu_seconds_total counter
+pbs_cgroup_process_cpu_seconds_total{comm="java",jobid="1000",pid="1002",runcount="1"} 2
+pbs_cgroup_process_cpu_seconds_total{comm="python",jobid="1000",pid="1001",runcount="1"} 10
+# HELP pbs_cgroup_process_rss_bytes Resident Set Size (RSS) of the process.
+# TYPE pbs_cgroup_process_rss_bytes gauge
+pbs_cgroup_process_rss_bytes{comm="java",jobid="1000",pid="1002",runcount="1"} 8.388608e+06
+pbs_cgroup_process_rss_bytes{comm="python",jobid="1000",pid="1001",runcount="1"} 1.048576e+06
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+
+	t.Run("Disabled with invalid procfs", func(t *testing.T) {
+		config.ProcRoot = "/nonexistent"
+		cgroupCollector := NewCgroupCollector(config)
+		if cgroupCollector.processMetrics != nil {
+			t.Errorf("NewCgroupCollector() processMetrics = %v, want nil", cgroupCollector.processMetrics)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"sync"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/process"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type PssMetrics struct {
+	pssDesc  *prometheus.Desc
+	swapDesc *prometheus.Desc
+}
+
+// Reading smaps_rollup walks every mapping of every process; results are
+// cached per cgroup and refreshed at most once per interval.
+type pssCache struct {
+	entries  map[string]pssEntry
+	interval time.Duration
+	mu       sync.Mutex
+}
+
+type pssEntry struct {
+	rollup  *process.MemoryRollup
+	updated time.Time
+}
+
+func NewPssMetrics() *PssMetrics {
+	return &PssMetrics{
+		pssDesc: prometheus.NewDesc(
+			"pbs_cgroup_mem_pss_bytes",
+			"Proportional Set Size (PSS): memory of tasks in the cgroup with shared pages divided between processes.",
+			defaultJobLabels,
+			nil,
+		),
+		swapDesc: prometheus.NewDesc(
+			"pbs_cgroup_mem_pss_swap_bytes",
+			"Total swap used by tasks in the cgroup from smaps_rollup.",
+			defaultJobLabels,
+			nil,
+		),
+	}
+}
+
+func (m *PssMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.pssDesc
+	ch <- m.swapDesc
+}
+
+func newPssCache(interval time.Duration) *pssCache {
+	return &pssCache{
+		entries:  make(map[string]pssEntry),
+		interval: interval,
+	}
+}
+
+// Returns the cached rollup for the cgroup, calling update once the entry is
+// older than the interval. Entries of removed cgroups are pruned.
+func (p *pssCache) get(cgroupPath string, update func() (*process.MemoryRollup, error)) (*process.MemoryRollup, error) {
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for path, entry := range p.entries {
+		if now.Sub(entry.updated) > 2*p.interval {
+			delete(p.entries, path)
+		}
+	}
+
+	if entry, ok := p.entries[cgroupPath]; ok && now.Sub(entry.updated) < p.interval {
+		return entry.rollup, nil
+	}
+
+	rollup, err := update()
+	if err != nil {
+		return nil, err
+	}
+	p.entries[cgroupPath] = pssEntry{
+		rollup:  rollup,
+		updated: now,
+	}
+
+	return rollup, nil
+}
+
+func (c *CgroupCollector) collectPss(ch chan<- prometheus.Metric, jobLabels []string, cgroupPath string, pids []uint64) {
+	rollup, err := c.pss.get(cgroupPath, func() (*process.MemoryRollup, error) {
+		return c.procFs.MemoryRollup(pids)
+	})
+	if err != nil {
+		c.logger.Error("Error reading job smaps_rollup", "err", err, "jobId", jobLabels[0])
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		c.pssMetrics.pssDesc,
+		prometheus.GaugeValue,
+		float64(rollup.Pss),
+		jobLabels...,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.pssMetrics.swapDesc,
+		prometheus.GaugeValue,
+		float64(rollup.Swap),
+		jobLabels...,
+	)
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/process"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestPssCache(t *testing.T) {
+	cache := newPssCache(time.Minute)
+	calls := 0
+	update := func() (*process.MemoryRollup, error) {
+		calls++
+		return &process.MemoryRollup{Pss: uint64(calls)}, nil
+	}
+
+	t.Run("Rate limited", func(t *testing.T) {
+		for range 3 {
+			got, err := cache.get("/1000", update)
+			if err != nil {
+				t.Fatalf("get() returned error: %v", err)
+			}
+			if got.Pss != 1 {
+				t.Errorf("get() = %d, want %d", got.Pss, 1)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("update called %d times, want 1", calls)
+		}
+	})
+
+	t.Run("Refresh expired", func(t *testing.T) {
+		entry := cache.entries["/1000"]
+		entry.updated = time.Now().Add(-90 * time.Second)
+		cache.entries["/1000"] = entry
+		got, err := cache.get("/1000", update)
+		if err != nil {
+			t.Fatalf("get() returned error: %v", err)
+		}
+		if got.Pss != 2 {
+			t.Errorf("get() = %d, want %d", got.Pss, 2)
+		}
+	})
+
+	t.Run("Prune removed", func(t *testing.T) {
+		cache.entries["/1001"] = pssEntry{updated: time.Now().Add(-time.Hour)}
+		if _, err := cache.get("/1000", update); err != nil {
+			t.Fatalf("get() returned error: %v", err)
+		}
+		if _, ok := cache.entries["/1001"]; ok {
+			t.Error("get() expected expired entry /1001 to be pruned")
+		}
+	})
+
+	t.Run("Update error", func(t *testing.T) {
+		_, err := cache.get("/1002", func() (*process.MemoryRollup, error) {
+			return nil, errors.New("permission denied")
+		})
+		if err == nil {
+			t.Error("get() expected error, got nil")
+		}
+		if _, ok := cache.entries["/1002"]; ok {
+			t.Error("get() expected failed update not to be cached")
+		}
+	})
+}
+
+func TestCollectPss(t *testing.T) {
+	config := configEnabled
+	config.ProcRoot = "/proc"
+	config.PssInterval = time.Minute
+	cgroupCollector := NewCgroupCollector(config)
+
+	ch := make(chan prometheus.Metric)
+	go func() {
+		defer close(ch)
+		pids := []uint64{uint64(os.Getpid())}
+		cgroupCollector.collectPss(ch, []string{"1000", "1"}, "/1000", pids)
+	}()
+
+	got := 0
+	want := reflect.TypeOf(*cgroupCollector.pssMetrics).NumField()
+	for range ch {
+		got++
+	}
+	if got != want {
+		t.Errorf("collectPss() = %d, want %d", got, want)
+	}
+}
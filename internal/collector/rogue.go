@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"context"
+	"log/slog"
+	"os/user"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/process"
+	"github.com/0nebody/pbs_exporter/internal/utils"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	rogueReasonNoJob      = "no_job"
+	rogueReasonOutsideJob = "outside_job"
+)
+
+type RogueCollector struct {
+	logger  *slog.Logger
+	metrics *RogueMetrics
+	minUid  uint64
+	procFs  *process.ProcFS
+
+	// CPU time is accumulated from per-process deltas, a sum over live
+	// processes would decrease whenever one exits. Totals of users without
+	// rogue processes are dropped after the timeout.
+	cpuTotal map[rogueKey]*rogueTotal
+	lastCpu  map[uint64]float64
+	mu       sync.Mutex
+	timeout  time.Duration
+}
+
+type RogueMetrics struct {
+	cpuDesc       *prometheus.Desc
+	processesDesc *prometheus.Desc
+}
+
+type rogueKey struct {
+	reason   string
+	uid      string
+	username string
+}
+
+type rogueTotal struct {
+	cpu      float64
+	lastSeen time.Time
+}
+
+func NewRogueCollector(config CollectorConfig) *RogueCollector {
+	rogueLabels := []string{"username", "uid", "reason"}
+	rogueMetrics := &RogueMetrics{
+		cpuDesc: prometheus.NewDesc(
+			"pbs_rogue_cpu_seconds_total",
+			"Total CPU time in seconds consumed by user processes outside of job cgroups.",
+			rogueLabels,
+			nil,
+		),
+		processesDesc: prometheus.NewDesc(
+			"pbs_rogue_processes",
+			"Number of user processes outside of job cgroups.",
+			rogueLabels,
+			nil,
+		),
+	}
+
+	procFs, err := process.NewProcFS(config.ProcRoot)
+	if err != nil {
+		config.Logger.Error("Unable to open procfs", "err", err, "path", config.ProcRoot)
+	}
+
+	return &RogueCollector{
+		logger:   config.Logger,
+		metrics:  rogueMetrics,
+		minUid:   config.RogueMinUid,
+		procFs:   procFs,
+		cpuTotal: make(map[rogueKey]*rogueTotal),
+		lastCpu:  make(map[uint64]float64),
+		timeout:  jobCacheTimeout * time.Second,
+	}
+}
+
+func (r *RogueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.metrics.cpuDesc
+	ch <- r.metrics.processesDesc
+}
+
+// Returns the names of users with a running job on this node.
+func jobUsers() map[string]bool {
+	users := make(map[string]bool)
+	for _, job := range jobCache.List() {
+		if job.IsRunning() {
+			users[job.JobUsername()] = true
+		}
+	}
+
+	return users
+}
+
+func isJobCgroup(cgroupPaths []string) bool {
+	for _, cgroupPath := range cgroupPaths {
+		if utils.GetCgroupJobId(cgroupPath) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *RogueCollector) Collect(ctx context.Context, ch chan<- prometheus.Metric) {
+	if r.procFs == nil {
+		r.logger.Error("Procfs is unavailable")
+		return
+	}
+	if jobCache == nil {
+		r.logger.Error("Job cache is uninitialised")
+		return
+	}
+
+	processes, err := r.procFs.AllProcesses()
+	if err != nil {
+		r.logger.Error("Error reading processes", "err", err)
+		return
+	}
+
+	now := time.Now()
+	users := jobUsers()
+	usernames := make(map[uint64]string)
+	counts := make(map[rogueKey]int)
+	currentCpu := make(map[uint64]float64)
+	deltas := make(map[rogueKey]float64)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, proc := range processes {
+		// totals are only updated once every process has been read, so
+		// deltas are not counted again after a cancelled collection
+		if ctx.Err() != nil {
+			r.logger.Error("Rogue process collection cancelled", "err", ctx.Err())
+			return
+		}
+		if proc.Uid < r.minUid {
+			continue
+		}
+
+		cgroupPaths, err := r.procFs.CgroupPaths(proc.Pid)
+		if err != nil {
+			if !process.IsExited(err) {
+				r.logger.Error("Error reading process cgroups", "err", err, "pid", proc.Pid)
+			}
+			continue
+		}
+		if isJobCgroup(cgroupPaths) {
+			continue
+		}
+
+		username, ok := usernames[proc.Uid]
+		if !ok {
+			username = strconv.FormatUint(proc.Uid, 10)
+			if u, err := user.LookupId(username); err == nil {
+				username = u.Username
+			}
+			usernames[proc.Uid] = username
+		}
+
+		key := rogueKey{
+			reason:   rogueReasonNoJob,
+			uid:      strconv.FormatUint(proc.Uid, 10),
+			username: username,
+		}
+		if users[username] {
+			key.reason = rogueReasonOutsideJob
+		}
+		counts[key]++
+
+		// processes first seen contribute their full CPU time
+		delta := proc.Cpu
+		if last, ok := r.lastCpu[proc.Pid]; ok && proc.Cpu >= last {
+			delta = proc.Cpu - last
+		}
+		deltas[key] += delta
+		currentCpu[proc.Pid] = proc.Cpu
+	}
+
+	for key, delta := range deltas {
+		total, ok := r.cpuTotal[key]
+		if !ok {
+			total = &rogueTotal{}
+			r.cpuTotal[key] = total
+		}
+		total.cpu += delta
+		total.lastSeen = now
+	}
+	r.lastCpu = currentCpu
+
+	for key, total := range r.cpuTotal {
+		if now.Sub(total.lastSeen) > r.timeout {
+			delete(r.cpuTotal, key)
+			continue
+		}
+
+		labels := []string{key.username, key.uid, key.reason}
+		ch <- prometheus.MustNewConstMetric(
+			r.metrics.cpuDesc,
+			prometheus.CounterValue,
+			total.cpu,
+			labels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			r.metrics.processesDesc,
+			prometheus.GaugeValue,
+			float64(counts[key]),
+			labels...,
+		)
+	}
+}
@@ -0,0 +1,176 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/pbsjob"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Writes a minimal /proc/<pid> tree for a process owned by uid in cgroup.
+func mockRogueProc(t *testing.T, root string, pid int, uid int, cgroup string, ticks int) {
+	t.Helper()
+	procPath := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(procPath, 0755); err != nil {
+		t.Fatalf("Failed to create proc dir: %v", err)
+	}
+
+	files := map[string]string{
+		"stat":   fmt.Sprintf("%d (app) S 1 %d %d 0 -1 0 0 0 0 0 %d 0 0 0 20 0 1 0 100 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n", pid, pid, pid, ticks),
+		"status": fmt.Sprintf("Name:\tapp\nUid:\t%d\t%d\t%d\t%d\n", uid, uid, uid, uid),
+		"comm":   "app\n",
+		"cgroup": fmt.Sprintf("0::%s\n", cgroup),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(procPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+// Context cancelled after Err has been checked n times.
+type cancelAfterContext struct {
+	context.Context
+	n int
+}
+
+func (c *cancelAfterContext) Err() error {
+	c.n--
+	if c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+func TestDescribeRogue(t *testing.T) {
+	rogueCollector := NewRogueCollector(configEnabled)
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		defer close(ch)
+		rogueCollector.Describe(ch)
+	}()
+
+	got := 0
+	want := reflect.TypeOf(*rogueCollector.metrics).NumField()
+	for desc := range ch {
+		got++
+
+		fqName := promDescFqname(desc.String())
+		if !strings.HasPrefix(fqName, "pbs_rogue_") {
+			t.Errorf("Describe() = %s, want: %s", fqName, "pbs_rogue_.*")
+		}
+	}
+
+	if got != want {
+		t.Errorf("Describe() = %d, want %d", got, want)
+	}
+}
+
+func TestCollectRogue(t *testing.T) {
+	currentUser, err := user.Current()
+	if err != nil {
+		t.Fatalf("Failed to get current user: %v", err)
+	}
+	uid := os.Getuid()
+
+	procRoot := t.TempDir()
+	// escaped job process, in job process, and process of user without a job
+	mockRogueProc(t, procRoot, 1000, uid, "/user.slice/user-1000.slice/session-1.scope", 100)
+	mockRogueProc(t, procRoot, 1001, uid, "/pbs_jobs.service/jobs/1000.pbs", 100)
+	mockRogueProc(t, procRoot, 1002, 99999, "/user.slice/user-99999.slice/session-2.scope", 100)
+
+	config := configEnabled
+	config.ProcRoot = procRoot
+	config.RogueMinUid = 0
+	rogueCollector := NewRogueCollector(config)
+	jobCache = pbsjob.NewJobCache(rogueCollector.logger, 60, 15*time.Second)
+	jobCache.Set("1000", &pbsjob.Job{
+		Hashname: "1000.pbs",
+		JobState: "R",
+		Euser:    currentUser.Username,
+		Stime:    time.Now().Unix(),
+		ResourceList: pbsjob.ResourceList{
+			Walltime: "01:00:00",
+		},
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newCollectorContext(rogueCollector))
+
+	want := fmt.Sprintf(`# HELP pbs_rogue_processes Number of user processes outside of job cgroups.
+# TYPE pbs_rogue_processes gauge
+pbs_rogue_processes{reason="no_job",uid="99999",username="99999"} 1
+pbs_rogue_processes{reason="outside_job",uid="%d",username="%s"} 1
+`, uid, currentUser.Username)
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "pbs_rogue_processes"); err != nil {
+		t.Errorf("GatherAndCompare() = %v", err)
+	}
+
+	t.Run("Accumulates CPU", func(t *testing.T) {
+		mockRogueProc(t, procRoot, 1000, uid, "/user.slice/user-1000.slice/session-1.scope", 300)
+		if err := os.RemoveAll(filepath.Join(procRoot, "1002")); err != nil {
+			t.Fatalf("Failed to remove proc: %v", err)
+		}
+
+		want := fmt.Sprintf(`# HELP pbs_rogue_cpu_seconds_total Total CPU time in seconds consumed by user processes outside of job cgroups.
+# TYPE pbs_rogue_cpu_seconds_total counter
+pbs_rogue_cpu_seconds_total{reason="no_job",uid="99999",username="99999"} 1
+pbs_rogue_cpu_seconds_total{reason="outside_job",uid="%d",username="%s"} 3
+`, uid, currentUser.Username)
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "pbs_rogue_cpu_seconds_total"); err != nil {
+			t.Errorf("GatherAndCompare() = %v", err)
+		}
+	})
+
+	t.Run("Cancelled collection", func(t *testing.T) {
+		mockRogueProc(t, procRoot, 1000, uid, "/user.slice/user-1000.slice/session-1.scope", 500)
+
+		// cancelled after the first process was read
+		ch := make(chan prometheus.Metric, 10)
+		rogueCollector.Collect(&cancelAfterContext{Context: context.Background(), n: 1}, ch)
+		close(ch)
+		if len(ch) != 0 {
+			t.Errorf("Collect() = %d metrics after cancellation, want 0", len(ch))
+		}
+
+		want := fmt.Sprintf(`# HELP pbs_rogue_cpu_seconds_total Total CPU time in seconds consumed by user processes outside of job cgroups.
+# TYPE pbs_rogue_cpu_seconds_total counter
+pbs_rogue_cpu_seconds_total{reason="no_job",uid="99999",username="99999"} 1
+pbs_rogue_cpu_seconds_total{reason="outside_job",uid="%d",username="%s"} 5
+`, uid, currentUser.Username)
+		if err := testutil.GatherAndCompare(registry, strings.NewReader(want), "pbs_rogue_cpu_seconds_total"); err != nil {
+			t.Errorf("GatherAndCompare() = %v", err)
+		}
+	})
+
+	t.Run("Expires exited users", func(t *testing.T) {
+		rogueCollector.timeout = 0
+		if err := os.RemoveAll(filepath.Join(procRoot, "1000")); err != nil {
+			t.Fatalf("Failed to remove proc: %v", err)
+		}
+
+		for _, metric := range []string{"pbs_rogue_cpu_seconds_total", "pbs_rogue_processes"} {
+			if got := testutil.CollectAndCount(registry, metric); got != 0 {
+				t.Errorf("CollectAndCount(%s) = %d after processes exited, want 0", metric, got)
+			}
+		}
+	})
+
+	t.Run("CollectAndLint", func(t *testing.T) {
+		lint, err := testutil.CollectAndLint(registry)
+		if err != nil {
+			t.Fatalf("CollectAndLint failed: %v", err)
+		}
+		if len(lint) > 0 {
+			t.Errorf("CollectAndLint found issues: %v", lint)
+		}
+	})
+}
@@ -0,0 +1,303 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type SliceMetrics struct {
+	cpuSystemDesc       *prometheus.Desc
+	cpuUsageDesc        *prometheus.Desc
+	cpuUserDesc         *prometheus.Desc
+	ioRbytesDesc        *prometheus.Desc
+	ioWbytesDesc        *prometheus.Desc
+	memRssDesc          *prometheus.Desc
+	memUsageDesc        *prometheus.Desc
+	outsideCpuUsageDesc *prometheus.Desc
+	outsideIoRbytesDesc *prometheus.Desc
+	outsideIoWbytesDesc *prometheus.Desc
+	outsideMemUsageDesc *prometheus.Desc
+}
+
+// Usage summed over top-level cgroups.
+type sliceUsage struct {
+	cpuUsage uint64
+	ioRbytes uint64
+	ioWbytes uint64
+	memUsage uint64
+}
+
+func NewSliceMetrics() *SliceMetrics {
+	sliceLabels := []string{"cgroup"}
+
+	return &SliceMetrics{
+		cpuSystemDesc: prometheus.NewDesc(
+			"pbs_cgroup_slice_cpu_system_seconds_total",
+			"Total system CPU time in seconds consumed by tasks in the top-level cgroup.",
+			sliceLabels,
+			nil,
+		),
+		cpuUsageDesc: prometheus.NewDesc(
+			"pbs_cgroup_slice_cpu_usage_seconds_total",
+			"Total CPU time in seconds consumed by tasks in the top-level cgroup.",
+			sliceLabels,
+			nil,
+		),
+		cpuUserDesc: prometheus.NewDesc(
+			"pbs_cgroup_slice_cpu_user_seconds_total",
+			"Total user CPU time in seconds consumed by tasks in the top-level cgroup.",
+			sliceLabels,
+			nil,
+		),
+		ioRbytesDesc: prometheus.NewDesc(
+			"pbs_cgroup_slice_io_read_bytes_total",
+			"Total bytes read from all devices by tasks in the top-level cgroup.",
+			sliceLabels,
+			nil,
+		),
+		ioWbytesDesc: prometheus.NewDesc(
+			"pbs_cgroup_slice_io_write_bytes_total",
+			"Total bytes written to all devices by tasks in the top-level cgroup.",
+			sliceLabels,
+			nil,
+		),
+		memRssDesc: prometheus.NewDesc(
+			"pbs_cgroup_slice_mem_rss_bytes",
+			"Resident Set Size (RSS): memory required to run tasks in the top-level cgroup.",
+			sliceLabels,
+			nil,
+		),
+		memUsageDesc: prometheus.NewDesc(
+			"pbs_cgroup_slice_mem_usage_bytes",
+			"Total memory used by tasks in the top-level cgroup.",
+			sliceLabels,
+			nil,
+		),
+		outsideCpuUsageDesc: prometheus.NewDesc(
+			"pbs_cgroup_outside_jobs_cpu_usage_seconds_total",
+			"Total CPU time in seconds consumed by tasks outside of the PBS jobs cgroup.",
+			nil,
+			nil,
+		),
+		outsideIoRbytesDesc: prometheus.NewDesc(
+			"pbs_cgroup_outside_jobs_io_read_bytes_total",
+			"Total bytes read by tasks outside of the PBS jobs cgroup.",
+			nil,
+			nil,
+		),
+		outsideIoWbytesDesc: prometheus.NewDesc(
+			"pbs_cgroup_outside_jobs_io_write_bytes_total",
+			"Total bytes written by tasks outside of the PBS jobs cgroup.",
+			nil,
+			nil,
+		),
+		outsideMemUsageDesc: prometheus.NewDesc(
+			"pbs_cgroup_outside_jobs_mem_usage_bytes",
+			"Total memory used by tasks outside of the PBS jobs cgroup.",
+			nil,
+			nil,
+		),
+	}
+}
+
+func (m *SliceMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.cpuSystemDesc
+	ch <- m.cpuUsageDesc
+	ch <- m.cpuUserDesc
+	ch <- m.ioRbytesDesc
+	ch <- m.ioWbytesDesc
+	ch <- m.memRssDesc
+	ch <- m.memUsageDesc
+	ch <- m.outsideCpuUsageDesc
+	ch <- m.outsideIoRbytesDesc
+	ch <- m.outsideIoWbytesDesc
+	ch <- m.outsideMemUsageDesc
+}
+
+// jobsParentCgroup returns the top-level cgroup containing PBS jobs, e.g.
+// /pbs_jobs.service for pbs_jobs.service/jobs.
+func jobsParentCgroup(cgroupPath string) string {
+	parent, _, _ := strings.Cut(strings.TrimPrefix(cgroupPath, "/"), "/")
+	return path.Join("/", parent)
+}
+
+// outsideJobsUsage returns usage of the root cgroup less the PBS jobs parent,
+// which includes tasks attached directly to the root cgroup. Memory is summed
+// over the other top-level cgroups when the root cgroup does not account it.
+func outsideJobsUsage(root *cgroups.Metrics, rootMemory bool, metrics []*cgroups.Metrics, jobsParent string) sliceUsage {
+	var jobs, siblings sliceUsage
+	for _, metric := range metrics {
+		usage := &siblings
+		if metric.Path == jobsParent {
+			usage = &jobs
+		}
+		rbytes, wbytes := ioBytes(metric.Io.Usage)
+		usage.cpuUsage += metric.Cpu.Usage
+		usage.ioRbytes += rbytes
+		usage.ioWbytes += wbytes
+		usage.memUsage += metric.Memory.Usage
+	}
+
+	rbytes, wbytes := ioBytes(root.Io.Usage)
+	usage := sliceUsage{
+		cpuUsage: subtractUsage(root.Cpu.Usage, jobs.cpuUsage),
+		ioRbytes: subtractUsage(rbytes, jobs.ioRbytes),
+		ioWbytes: subtractUsage(wbytes, jobs.ioWbytes),
+		memUsage: siblings.memUsage,
+	}
+	if rootMemory {
+		usage.memUsage = subtractUsage(root.Memory.Usage, jobs.memUsage)
+	}
+
+	return usage
+}
+
+func subtractUsage(usage, jobs uint64) uint64 {
+	if usage < jobs {
+		return 0
+	}
+	return usage - jobs
+}
+
+// Counters of usage outside of PBS jobs. Root less jobs usage decreases when
+// usage of removed job cgroups is no longer accounted in the jobs parent, so
+// counters are kept at their highest value rather than reset.
+type outsideCounters struct {
+	last sliceUsage
+	mu   sync.Mutex
+}
+
+func (o *outsideCounters) update(usage sliceUsage) sliceUsage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.last.cpuUsage = max(o.last.cpuUsage, usage.cpuUsage)
+	o.last.ioRbytes = max(o.last.ioRbytes, usage.ioRbytes)
+	o.last.ioWbytes = max(o.last.ioWbytes, usage.ioWbytes)
+	usage.cpuUsage, usage.ioRbytes, usage.ioWbytes = o.last.cpuUsage, o.last.ioRbytes, o.last.ioWbytes
+
+	return usage
+}
+
+// getRootCgroupStats returns stats of the root cgroup, and whether it
+// accounts memory; the v2 root cgroup has no memory.current.
+func getRootCgroupStats(root string) (*cgroups.Metrics, bool, error) {
+	manager := cgroups.NewCgroupManager(root)
+	cgroup, err := manager.Load("/")
+	if err != nil {
+		return nil, false, fmt.Errorf("loading root cgroup: %w", err)
+	}
+
+	metrics, err := cgroup.Stat()
+	if err != nil {
+		return nil, false, fmt.Errorf("getting root cgroup stats: %w", err)
+	}
+
+	return metrics, manager.Version() != "v2", nil
+}
+
+func ioBytes(usage []cgroups.IoUsage) (uint64, uint64) {
+	var rbytes, wbytes uint64
+	for _, device := range usage {
+		rbytes += device.Rbytes
+		wbytes += device.Wbytes
+	}
+	return rbytes, wbytes
+}
+
+func (c *CgroupCollector) collectSlices(ctx context.Context, ch chan<- prometheus.Metric) {
+	metrics, err := getCgroupStats(ctx, c.cgroupRoot, "", c.logger)
+	if err != nil {
+		c.logger.Error("Failed to get top-level cgroup metrics", "err", err)
+		return
+	}
+
+	c.collectSliceMetrics(ch, metrics)
+
+	root, rootMemory, err := getRootCgroupStats(c.cgroupRoot)
+	if err != nil {
+		c.logger.Error("Failed to get root cgroup metrics", "err", err)
+		return
+	}
+	outside := c.outside.update(outsideJobsUsage(root, rootMemory, metrics, jobsParentCgroup(c.cgroupPath)))
+	c.collectOutsideJobs(ch, outside)
+}
+
+func (c *CgroupCollector) collectSliceMetrics(ch chan<- prometheus.Metric, metrics []*cgroups.Metrics) {
+	for _, metric := range metrics {
+		sliceLabels := []string{strings.TrimPrefix(metric.Path, "/")}
+		rbytes, wbytes := ioBytes(metric.Io.Usage)
+
+		ch <- prometheus.MustNewConstMetric(
+			c.sliceMetrics.cpuSystemDesc,
+			prometheus.CounterValue,
+			float64(metric.Cpu.System),
+			sliceLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.sliceMetrics.cpuUsageDesc,
+			prometheus.CounterValue,
+			float64(metric.Cpu.Usage),
+			sliceLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.sliceMetrics.cpuUserDesc,
+			prometheus.CounterValue,
+			float64(metric.Cpu.User),
+			sliceLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.sliceMetrics.ioRbytesDesc,
+			prometheus.CounterValue,
+			float64(rbytes),
+			sliceLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.sliceMetrics.ioWbytesDesc,
+			prometheus.CounterValue,
+			float64(wbytes),
+			sliceLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.sliceMetrics.memRssDesc,
+			prometheus.GaugeValue,
+			float64(metric.Memory.Rss),
+			sliceLabels...,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			c.sliceMetrics.memUsageDesc,
+			prometheus.GaugeValue,
+			float64(metric.Memory.Usage),
+			sliceLabels...,
+		)
+	}
+}
+
+func (c *CgroupCollector) collectOutsideJobs(ch chan<- prometheus.Metric, outside sliceUsage) {
+	ch <- prometheus.MustNewConstMetric(
+		c.sliceMetrics.outsideCpuUsageDesc,
+		prometheus.CounterValue,
+		float64(outside.cpuUsage),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.sliceMetrics.outsideIoRbytesDesc,
+		prometheus.CounterValue,
+		float64(outside.ioRbytes),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.sliceMetrics.outsideIoWbytesDesc,
+		prometheus.CounterValue,
+		float64(outside.ioWbytes),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.sliceMetrics.outsideMemUsageDesc,
+		prometheus.GaugeValue,
+		float64(outside.memUsage),
+	)
+}
@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestJobsParentCgroup(t *testing.T) {
+	tests := []struct {
+		cgroupPath string
+		want       string
+	}{
+		{"pbs_jobs.service/jobs", "/pbs_jobs.service"},
+		{"/pbs_jobs.service/jobid", "/pbs_jobs.service"},
+		{"pbs_jobs.service", "/pbs_jobs.service"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cgroupPath, func(t *testing.T) {
+			got := jobsParentCgroup(tt.cgroupPath)
+			if got != tt.want {
+				t.Errorf("jobsParentCgroup(%q) = %q, want %q", tt.cgroupPath, got, tt.want)
+			}
+		})
+	}
+}
+
+var sliceTestMetrics = []*cgroups.Metrics{
+	{
+		Path:   "/pbs_jobs.service",
+		Cpu:    cgroups.CPU{Usage: 1000},
+		Io:     cgroups.IO{Usage: []cgroups.IoUsage{{Rbytes: 1000, Wbytes: 1000}}},
+		Memory: cgroups.Memory{Usage: 1000},
+	},
+	{
+		Path:   "/system.slice",
+		Cpu:    cgroups.CPU{Usage: 10},
+		Io:     cgroups.IO{Usage: []cgroups.IoUsage{{Rbytes: 1, Wbytes: 2}, {Rbytes: 3, Wbytes: 4}}},
+		Memory: cgroups.Memory{Usage: 100},
+	},
+	{
+		Path:   "/user.slice",
+		Cpu:    cgroups.CPU{Usage: 5},
+		Memory: cgroups.Memory{Usage: 50},
+	},
+}
+
+var sliceTestRoot = &cgroups.Metrics{
+	Path:   "/",
+	Cpu:    cgroups.CPU{Usage: 1020},
+	Io:     cgroups.IO{Usage: []cgroups.IoUsage{{Rbytes: 1010, Wbytes: 1020}}},
+	Memory: cgroups.Memory{Usage: 1200},
+}
+
+func TestOutsideJobsUsage(t *testing.T) {
+	tests := []struct {
+		name       string
+		root       *cgroups.Metrics
+		rootMemory bool
+		want       sliceUsage
+	}{
+		{
+			// tasks in the root cgroup are counted as outside jobs
+			name:       "Root cgroup",
+			root:       sliceTestRoot,
+			rootMemory: true,
+			want:       sliceUsage{cpuUsage: 20, ioRbytes: 10, ioWbytes: 20, memUsage: 200},
+		},
+		{
+			// memory is not accounted in the v2 root cgroup
+			name: "Root cgroup without memory",
+			root: sliceTestRoot,
+			want: sliceUsage{cpuUsage: 20, ioRbytes: 10, ioWbytes: 20, memUsage: 150},
+		},
+		{
+			name:       "Root cgroup below jobs",
+			root:       &cgroups.Metrics{Path: "/", Cpu: cgroups.CPU{Usage: 500}},
+			rootMemory: true,
+			want:       sliceUsage{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := outsideJobsUsage(tt.root, tt.rootMemory, sliceTestMetrics, "/pbs_jobs.service")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("outsideJobsUsage() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOutsideCounters(t *testing.T) {
+	counters := &outsideCounters{}
+	counters.update(sliceUsage{cpuUsage: 20, ioRbytes: 10, ioWbytes: 20, memUsage: 200})
+
+	// usage of a removed job cgroup no longer subtracted from the root
+	got := counters.update(sliceUsage{cpuUsage: 15, ioRbytes: 30, ioWbytes: 5, memUsage: 100})
+	want := sliceUsage{cpuUsage: 20, ioRbytes: 30, ioWbytes: 20, memUsage: 100}
+	if got != want {
+		t.Errorf("update() = %+v, want %+v", got, want)
+	}
+}
+
+// sliceCollector collects slice metrics from fixed cgroup stats.
+type sliceCollector struct {
+	collector *CgroupCollector
+	metrics   []*cgroups.Metrics
+	root      *cgroups.Metrics
+}
+
+func (c *sliceCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.sliceMetrics.Describe(ch)
+}
+
+func (c *sliceCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collector.collectSliceMetrics(ch, c.metrics)
+	outside := outsideJobsUsage(c.root, true, c.metrics, jobsParentCgroup(c.collector.cgroupPath))
+	c.collector.collectOutsideJobs(ch, c.collector.outside.update(outside))
+}
+
+func TestCollectSliceMetrics(t *testing.T) {
+	config := configEnabled
+	config.CgroupPath = "pbs_jobs.service/jobs"
+	config.EnableSliceMetrics = true
+	collector := &sliceCollector{
+		collector: NewCgroupCollector(config),
+		metrics:   sliceTestMetrics,
+		root:      sliceTestRoot,
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	want := `# HELP pbs_cgroup_outside_jobs_cpu_usage_seconds_total Total CPU time in seconds consumed by tasks outside of the PBS jobs cgroup.
+# TYPE pbs_cgroup_outside_jobs_cpu_usage_seconds_total counter
+pbs_cgroup_outside_jobs_cpu_usage_seconds_total 20
+# HELP pbs_cgroup_outside_jobs_io_read_bytes_total Total bytes read by tasks outside of the PBS jobs cgroup.
+# TYPE pbs_cgroup_outside_jobs_io_read_bytes_total counter
+pbs_cgroup_outside_jobs_io_read_bytes_total 10
+# HELP pbs_cgroup_outside_jobs_mem_usage_bytes Total memory used by tasks outside of the PBS jobs cgroup.
+# TYPE pbs_cgroup_outside_jobs_mem_usage_bytes gauge
+pbs_cgroup_outside_jobs_mem_usage_bytes 200
+# HELP pbs_cgroup_slice_cpu_usage_seconds_total Total CPU time in seconds consumed by tasks in the top-level cgroup.
+# TYPE pbs_cgroup_slice_cpu_usage_seconds_total counter
+pbs_cgroup_slice_cpu_usage_seconds_total{cgroup="pbs_jobs.service"} 1000
+pbs_cgroup_slice_cpu_usage_seconds_total{cgroup="system.slice"} 10
+pbs_cgroup_slice_cpu_usage_seconds_total{cgroup="user.slice"} 5
+`
+	metrics := []string{
+		"pbs_cgroup_outside_jobs_cpu_usage_seconds_total",
+		"pbs_cgroup_outside_jobs_io_read_bytes_total",
+		"pbs_cgroup_outside_jobs_mem_usage_bytes",
+		"pbs_cgroup_slice_cpu_usage_seconds_total",
+	}
+	if err := testutil.CollectAndCompare(registry, strings.NewReader(want), metrics...); err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+
+	t.Run("Job exits", func(t *testing.T) {
+		// usage of the job leaves the jobs parent before the root cgroup
+		collector.metrics = []*cgroups.Metrics{
+			{Path: "/pbs_jobs.service", Cpu: cgroups.CPU{Usage: 1010}},
+			{Path: "/system.slice", Cpu: cgroups.CPU{Usage: 10}},
+		}
+		collector.root = &cgroups.Metrics{Path: "/", Cpu: cgroups.CPU{Usage: 1025}}
+
+		want := `# HELP pbs_cgroup_outside_jobs_cpu_usage_seconds_total Total CPU time in seconds consumed by tasks outside of the PBS jobs cgroup.
+# TYPE pbs_cgroup_outside_jobs_cpu_usage_seconds_total counter
+pbs_cgroup_outside_jobs_cpu_usage_seconds_total 20
+`
+		if err := testutil.CollectAndCompare(registry, strings.NewReader(want), "pbs_cgroup_outside_jobs_cpu_usage_seconds_total"); err != nil {
+			t.Errorf("CollectAndCompare() returned error: %v", err)
+		}
+	})
+}
+
+func TestSliceMetricsDescribe(t *testing.T) {
+	config := configEnabled
+	config.EnableSliceMetrics = true
+	cgroupCollector := NewCgroupCollector(config)
+
+	ch := make(chan *prometheus.Desc)
+	go func() {
+		defer close(ch)
+		cgroupCollector.sliceMetrics.Describe(ch)
+	}()
+
+	got := 0
+	want := reflect.TypeOf(SliceMetrics{}).NumField()
+	for range ch {
+		got++
+	}
+	if got != want {
+		t.Errorf("Describe() = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,61 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type StateMetrics struct {
+	processStatesDesc *prometheus.Desc
+	threadStatesDesc  *prometheus.Desc
+}
+
+func NewStateMetrics() *StateMetrics {
+	stateJobLabels := append(defaultJobLabels, "state")
+
+	return &StateMetrics{
+		processStatesDesc: prometheus.NewDesc(
+			"pbs_cgroup_process_states",
+			"Number of processes in the cgroup by scheduler state.",
+			stateJobLabels,
+			nil,
+		),
+		threadStatesDesc: prometheus.NewDesc(
+			"pbs_cgroup_thread_states",
+			"Number of threads in the cgroup by scheduler state.",
+			stateJobLabels,
+			nil,
+		),
+	}
+}
+
+func (m *StateMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.processStatesDesc
+	ch <- m.threadStatesDesc
+}
+
+func (c *CgroupCollector) collectStates(ch chan<- prometheus.Metric, jobLabels []string, pids []uint64, threads []uint64) {
+	tasks := []struct {
+		desc *prometheus.Desc
+		ids  []uint64
+	}{
+		{c.stateMetrics.processStatesDesc, pids},
+		{c.stateMetrics.threadStatesDesc, threads},
+	}
+
+	for _, task := range tasks {
+		states, err := c.procFs.States(task.ids)
+		if err != nil {
+			c.logger.Error("Error reading job task states", "err", err, "jobId", jobLabels[0])
+			continue
+		}
+
+		for state, count := range states {
+			ch <- prometheus.MustNewConstMetric(
+				task.desc,
+				prometheus.GaugeValue,
+				float64(count),
+				append(jobLabels, state)...,
+			)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// Writes /proc/<pid> stat, status and comm of a process in state using ticks
+// of both user and system CPU time.
+func mockProc(t *testing.T, root string, pid uint64, comm string, state string, ticks int, rssKb int) {
+	t.Helper()
+	procPath := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(procPath, 0755); err != nil {
+		t.Fatalf("Failed to create proc dir: %v", err)
+	}
+
+	files := map[string]string{
+		"stat":   fmt.Sprintf("%d (%s) %s 1 %d %d 0 -1 4194304 100 0 0 0 %d %d 0 0 20 0 2 0 100 1000000 100 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n", pid, comm, state, pid, pid, ticks, ticks),
+		"status": fmt.Sprintf("Name:\t%s\nState:\t%s\nPid:\t%d\nUid:\t1000\t1000\t1000\t1000\nVmRSS:\t%d kB\nThreads:\t2\n", comm, state, pid, rssKb),
+		"comm":   comm + "\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(procPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+type stateCollector struct {
+	collector *CgroupCollector
+	pids      []uint64
+	threads   []uint64
+}
+
+func (c *stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.stateMetrics.Describe(ch)
+}
+
+func (c *stateCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collector.collectStates(ch, []string{"1000", "1"}, c.pids, c.threads)
+}
+
+func TestCollectStates(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProc(t, procRoot, 1000, "python", "R", 0, 0)
+	mockProc(t, procRoot, 1001, "python", "S", 0, 0)
+	mockProc(t, procRoot, 1002, "defunct", "Z", 0, 0)
+	// threads of 1000 are read from /proc/<tid>
+	mockProc(t, procRoot, 1003, "python", "R", 0, 0)
+	mockProc(t, procRoot, 1004, "python", "D", 0, 0)
+	mockProc(t, procRoot, 1005, "python", "t", 0, 0)
+
+	config := configEnabled
+	config.ProcRoot = procRoot
+	config.EnableProcessStates = true
+	collector := &stateCollector{
+		collector: NewCgroupCollector(config),
+		// exited tasks are skipped
+		pids:    []uint64{1000, 1001, 1002, 1010},
+		threads: []uint64{1000, 1003, 1004, 1005, 1001, 1002, 1011},
+	}
+
+	want := `# HELP pbs_cgroup_process_states Number of processes in the cgroup by scheduler state.
+# TYPE pbs_cgroup_process_states gauge
+pbs_cgroup_process_states{jobid="1000",runcount="1",state="running"} 1
+pbs_cgroup_process_states{jobid="1000",runcount="1",state="sleeping"} 1
+pbs_cgroup_process_states{jobid="1000",runcount="1",state="uninterruptible"} 0
+pbs_cgroup_process_states{jobid="1000",runcount="1",state="zombie"} 1
+# HELP pbs_cgroup_thread_states Number of threads in the cgroup by scheduler state.
+# TYPE pbs_cgroup_thread_states gauge
+pbs_cgroup_thread_states{jobid="1000",runcount="1",state="running"} 2
+pbs_cgroup_thread_states{jobid="1000",runcount="1",state="sleeping"} 1
+pbs_cgroup_thread_states{jobid="1000",runcount="1",state="tracing_stop"} 1
+pbs_cgroup_thread_states{jobid="1000",runcount="1",state="uninterruptible"} 1
+pbs_cgroup_thread_states{jobid="1000",runcount="1",state="zombie"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want)); err != nil {
+		t.Errorf("CollectAndCompare() returned error: %v", err)
+	}
+}
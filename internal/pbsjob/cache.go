@@ -85,6 +85,21 @@ func (c *JobCache) List() []Job {
 	return activeJobs
 }
 
+// Returns the IDs of jobs which have not been deleted.
+func (c *JobCache) RunningJobIds() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var jobIds []string
+	for jobId, job := range c.jobs {
+		if job.isRunning {
+			jobIds = append(jobIds, jobId)
+		}
+	}
+
+	return jobIds
+}
+
 func (c *JobCache) Get(jobId string) (Job, bool) {
 	now := time.Now().Unix()
 
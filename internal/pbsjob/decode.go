@@ -6,6 +6,8 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
+	"iter"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -22,17 +24,77 @@ func (e *ErrUnknownJobAttribute) Error() string {
 	return fmt.Sprintf("attribute '%s', resource: '%s' unknown", e.Name, e.Resource)
 }
 
+type ErrUnknownJobFileLayout struct {
+	Version int32
+}
+
+func (e *ErrUnknownJobFileLayout) Error() string {
+	return fmt.Sprintf("job file version %d: attribute sentinel '%s' not found", e.Version, JobAttrSentinel)
+}
+
+type ErrInvalidJobAttrHeader struct {
+	Offset int64
+	Field  string
+	Size   int64
+	Limit  int64
+}
+
+func (e *ErrInvalidJobAttrHeader) Error() string {
+	if e.Size < 0 {
+		return fmt.Sprintf("job attribute at offset %d has negative %s size %d", e.Offset, e.Field, e.Size)
+	}
+	return fmt.Sprintf("job attribute at offset %d has %s size %d exceeding limit %d", e.Offset, e.Field, e.Size, e.Limit)
+}
+
 type Decoder struct {
-	r      *bufio.Reader
-	b      []byte
-	strict bool
+	r        *bufio.Reader
+	b        []byte
+	file     *JobFile // captures the decoded job file when set
+	header   JobAttrHeader
+	keepFile bool
+	offset   int64
+	size     int64 // -1 when the size of the reader is unknown
+	strict   bool
 }
 
 func NewDecoder(r io.Reader) *Decoder {
 	return &Decoder{
-		r:      bufio.NewReaderSize(r, 256),
-		b:      make([]byte, 1024),
-		strict: false,
+		r:        bufio.NewReaderSize(r, jobFileSearchLimit),
+		b:        make([]byte, 1024),
+		keepFile: true,
+		size:     readerSize(r),
+		strict:   false,
+	}
+}
+
+// Reset discards any buffered data and decodes from r, reusing the buffers of
+// the decoder.
+func (dec *Decoder) Reset(r io.Reader) {
+	dec.r.Reset(r)
+	dec.file = nil
+	dec.offset = 0
+	dec.size = readerSize(r)
+}
+
+// Returns the number of unread bytes of fixed size readers, or -1.
+func readerSize(r io.Reader) int64 {
+	switch r := r.(type) {
+	case *bytes.Reader:
+		return int64(r.Len())
+	case *strings.Reader:
+		return int64(r.Len())
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	default:
+		return -1
 	}
 }
 
@@ -40,6 +102,12 @@ func (dec *Decoder) setStrict(b bool) {
 	dec.strict = b
 }
 
+// Jobs which are only read have no need for job.File, skipping it avoids
+// copying every attribute.
+func (dec *Decoder) setKeepFile(b bool) {
+	dec.keepFile = b
+}
+
 func (dec *Decoder) decodeHeader(header *JobHeader) error {
 	if err := binary.Read(dec.r, binary.LittleEndian, header); err != nil {
 		return fmt.Errorf("reading header: %w", err)
@@ -57,118 +125,313 @@ func (dec *Decoder) parseAttributeValue(value string, attr reflect.Value, separa
 		return fmt.Errorf("job attribute '%v' is unassignable", attr)
 	}
 
-	switch attr.Kind() {
+	return newValueSetter(attr.Type(), separator)(attr, []byte(value))
+}
+
+func parseBoolValue(value []byte) (bool, error) {
+	if len(value) == 0 {
+		return false, nil
+	}
+	bValue, err := strconv.ParseBool(string(value))
+	if err != nil {
+		return false, fmt.Errorf("parsing bool job attribute '%s': %w", value, err)
+	}
+	return bValue, nil
+}
+
+func parseIntValue(value []byte) (int64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	// plain integers are parsed without allocating, sizes such as 92gb fall
+	// back to utils.ParseBytes
+	if intValue, err := strconv.ParseInt(string(value), 10, 64); err == nil {
+		return intValue, nil
+	}
+	intValue, err := utils.ParseBytes(string(value))
+	if err != nil {
+		return 0, fmt.Errorf("parsing int job attribute '%s': %w", value, err)
+	}
+	return intValue, nil
+}
+
+func parseUintValue(value []byte) (uint64, error) {
+	if len(value) == 0 {
+		return 0, nil
+	}
+	uintValue, err := strconv.ParseUint(string(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parsing uint job attribute '%s': %w", value, err)
+	}
+	return uintValue, nil
+}
+
+// Returns a setter parsing attribute values into values of type t. Values are
+// parsed from the attribute bytes so only strings kept on the job allocate.
+func newValueSetter(t reflect.Type, separator string) valueSetter {
+	switch t.Kind() {
 	case reflect.Bool:
-		if value == "" {
-			value = "false"
-		}
-		bValue, err := strconv.ParseBool(value)
-		if err != nil {
-			return fmt.Errorf("parsing bool job attribute '%v': %w", value, err)
+		return func(v reflect.Value, value []byte) error {
+			bValue, err := parseBoolValue(value)
+			if err != nil {
+				return err
+			}
+			v.SetBool(bValue)
+			return nil
 		}
-		attr.SetBool(bValue)
 
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		if value == "" {
-			attr.SetInt(0)
+		return func(v reflect.Value, value []byte) error {
+			intValue, err := parseIntValue(value)
+			if err != nil {
+				return err
+			}
+			v.SetInt(intValue)
 			return nil
 		}
-		if intValue, err := utils.ParseBytes(value); err == nil {
-			attr.SetInt(intValue)
-		} else {
-			return fmt.Errorf("parsing int job attribute '%v': %w", value, err)
-		}
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		if value == "" {
-			attr.SetUint(0)
+		return func(v reflect.Value, value []byte) error {
+			uintValue, err := parseUintValue(value)
+			if err != nil {
+				return err
+			}
+			v.SetUint(uintValue)
 			return nil
 		}
-		uintValue, err := strconv.ParseUint(value, 10, 64)
-		if err != nil {
-			return fmt.Errorf("parsing uint job attribute '%v': %w", value, err)
-		}
-		attr.SetUint(uintValue)
 
 	case reflect.String:
-		attr.SetString(value)
+		return func(v reflect.Value, value []byte) error {
+			v.SetString(string(value))
+			return nil
+		}
 
 	case reflect.Slice:
-		var values []string
-		if value != "" {
-			values = strings.Split(value, separator)
-		}
-		s := reflect.MakeSlice(attr.Type(), len(values), len(values))
-		for i := range values {
-			if err := dec.parseAttributeValue(values[i], s.Index(i), separator); err != nil {
-				return fmt.Errorf("parsing %v slice: %w", attr.Type(), err)
+		sep := []byte(separator)
+		elem := newValueSetter(t.Elem(), separator)
+		return func(v reflect.Value, value []byte) error {
+			n := 0
+			if len(value) > 0 {
+				n = bytes.Count(value, sep) + 1
+			}
+			s := reflect.MakeSlice(t, n, n)
+			for i := range n {
+				var part []byte
+				part, value, _ = bytes.Cut(value, sep)
+				if err := elem(s.Index(i), part); err != nil {
+					return fmt.Errorf("parsing %v slice: %w", t, err)
+				}
 			}
+			v.Set(s)
+			return nil
 		}
-		attr.Set(s)
 
 	default:
-		return fmt.Errorf("invalid data type %v", attr.Kind())
+		return func(v reflect.Value, value []byte) error {
+			return fmt.Errorf("invalid data type %v", t.Kind())
+		}
 	}
-
-	return nil
 }
 
-func (dec *Decoder) decodeAttributeValue(job *Job, attr *JobAttr) error {
-	jobVal := reflect.ValueOf(job).Elem()
+// Returns a setter of the job field at index. The field index is resolved once
+// so setters only walk it with FieldByIndex when the job is decoded.
+func newFieldSetter(t reflect.Type, index []int, separator string) fieldSetter {
+	fieldType := t.FieldByIndex(index).Type
+	field := func(job *Job) reflect.Value {
+		return reflect.ValueOf(job).Elem().FieldByIndex(index)
+	}
 
-	if key, ok := jobMap[JobMapKey{Name: attr.Name, Resource: attr.Resource}]; ok {
-		attrVal := jobVal.FieldByIndex(key.Index)
-		if err := dec.parseAttributeValue(attr.Value, attrVal, key.Separator); err != nil {
-			return fmt.Errorf("parsing key %v.%v: %w", attr.Name, attr.Resource, err)
+	switch fieldType.Kind() {
+	case reflect.Bool:
+		return func(job *Job, value []byte) error {
+			bValue, err := parseBoolValue(value)
+			if err != nil {
+				return err
+			}
+			field(job).SetBool(bValue)
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(job *Job, value []byte) error {
+			intValue, err := parseIntValue(value)
+			if err != nil {
+				return err
+			}
+			field(job).SetInt(intValue)
+			return nil
+		}
+	case reflect.String:
+		return func(job *Job, value []byte) error {
+			field(job).SetString(string(value))
+			return nil
+		}
+	case reflect.Slice:
+		if fieldType.Elem().Kind() == reflect.String {
+			sep := []byte(separator)
+			return func(job *Job, value []byte) error {
+				s := []string{}
+				if len(value) > 0 {
+					s = make([]string, 0, bytes.Count(value, sep)+1)
+					for part := range bytes.SplitSeq(value, sep) {
+						s = append(s, string(part))
+					}
+				}
+				field(job).Set(reflect.ValueOf(s))
+				return nil
+			}
 		}
-	} else if dec.strict {
-		return &ErrUnknownJobAttribute{Name: attr.Name, Resource: attr.Resource}
 	}
 
-	return nil
+	set := newValueSetter(fieldType, separator)
+	return func(job *Job, value []byte) error {
+		return set(field(job), value)
+	}
+}
+
+// Sets the job field of the attribute from the precompiled setter table,
+// returning the setter or nil when the attribute is unknown to Job.
+func (dec *Decoder) decodeAttributeValue(job *Job, name []byte, resource []byte, value []byte) (*jobSetter, error) {
+	setter, ok := jobSetters[string(name)][string(resource)]
+	if !ok {
+		if dec.strict {
+			return nil, &ErrUnknownJobAttribute{Name: string(name), Resource: string(resource)}
+		}
+		return nil, nil
+	}
+
+	if err := setter.setJob(job, value); err != nil {
+		return nil, fmt.Errorf("parsing key %s.%s: %w", name, resource, err)
+	}
+
+	return setter, nil
 }
 
 func (dec *Decoder) decodeAttributeHeader(header *JobAttrHeader, attr *JobAttr) error {
-	if err := binary.Read(dec.r, binary.LittleEndian, header); err != nil {
+	name, resource, value, err := dec.readAttribute(header)
+	if err != nil {
+		return err
+	}
+
+	attr.Name = string(name)
+	attr.Resource = string(resource)
+	attr.Value = string(value)
+	attr.Flags = header.Flags
+
+	return nil
+}
+
+// Reads the next attribute. Name, resource and value are slices of the
+// decoder buffer which are only valid until the next read.
+func (dec *Decoder) readAttribute(header *JobAttrHeader) (name []byte, resource []byte, value []byte, err error) {
+	b := dec.b[:jobAttrHeaderSize]
+	if _, err := io.ReadFull(dec.r, b); err != nil {
 		if err == io.EOF {
-			return nil
+			err = io.ErrUnexpectedEOF
 		}
-		return fmt.Errorf("reading job attribute header: %w", err)
+		return nil, nil, nil, fmt.Errorf("reading job attribute header: %w", err)
+	}
+	if _, err := binary.Decode(b, binary.LittleEndian, header); err != nil {
+		return nil, nil, nil, fmt.Errorf("reading job attribute header: %w", err)
 	}
 
 	// dummy attribute header indicating end of attribute list
 	if header.Length == JobAttrEndFlag {
-		return io.EOF
+		if dec.file != nil {
+			return nil, nil, nil, dec.decodeTrailer(b)
+		}
+		return nil, nil, nil, io.EOF
 	}
 
-	// read into byte slice as attribute uses sizes over reliable delimiter
-	requiredBuf := max(header.Name, header.Resource, header.Value)
-	if cap(dec.b) < int(requiredBuf) {
-		dec.b = make([]byte, requiredBuf)
+	if err := dec.validateAttributeHeader(header); err != nil {
+		return nil, nil, nil, err
+	}
+	dec.offset += int64(header.Length)
+
+	// read the attribute body as it uses sizes over reliable delimiters; kept
+	// attributes are read into their own buffer as the decoder buffer is reused
+	var raw, body []byte
+	if dec.file != nil {
+		raw = make([]byte, header.Length)
+		copy(raw, b)
+		body = raw[jobAttrHeaderSize:]
+	} else {
+		size := int(header.Length) - jobAttrHeaderSize
+		if cap(dec.b) < size {
+			dec.b = make([]byte, size)
+		}
+		body = dec.b[:size]
 	}
+	if _, err := io.ReadFull(dec.r, body); err != nil {
+		return nil, nil, nil, fmt.Errorf("reading job attribute: %w", err)
+	}
+
+	name, body = body[:header.Name], body[header.Name:]
+	resource, body = body[:header.Resource], body[header.Resource:]
+	value = body[:header.Value]
+
+	// value is suffixed with 2 bytes; the last may not be null
+	name = bytes.Trim(name, "\x00")
+	resource = bytes.Trim(resource, "\x00")
+	value = bytes.Trim(value[:max(0, len(value)-1)], "\x00")
+
+	if dec.file != nil {
+		attr := JobAttr{Value: string(value), Flags: header.Flags}
+		if setter, ok := jobSetters[string(name)][string(resource)]; ok {
+			attr.Name, attr.Resource = setter.key.Name, setter.key.Resource
+		} else {
+			attr.Name, attr.Resource = string(name), string(resource)
+		}
+		dec.file.Attrs = append(dec.file.Attrs, JobFileAttr{JobAttr: attr, Raw: raw})
+	}
+
+	return name, resource, value, nil
+}
 
-	dec.b = dec.b[:header.Name]
-	if _, err := io.ReadFull(dec.r, dec.b); err != nil {
-		return fmt.Errorf("reading job attribute name: %w", err)
+// Keeps the end of attributes header and anything after it for Encode.
+func (dec *Decoder) decodeTrailer(header []byte) error {
+	trailer := bytes.Clone(header)
+	// read past the limit to tell a trailer at the limit from a longer one
+	rest, err := io.ReadAll(io.LimitReader(dec.r, JobAttrValueLimit+1))
+	if err != nil {
+		return fmt.Errorf("reading job file trailer: %w", err)
+	}
+	if len(rest) > JobAttrValueLimit {
+		return fmt.Errorf("job file trailer exceeds limit %d", JobAttrValueLimit)
 	}
-	attr.Name = string(bytes.Trim(dec.b, "\x00"))
+	dec.file.Trailer = append(trailer, rest...)
 
-	dec.b = dec.b[:header.Resource]
-	if _, err := io.ReadFull(dec.r, dec.b); err != nil {
-		return fmt.Errorf("reading job attribute resource: %w", err)
+	return io.EOF
+}
+
+// Checks attribute sizes read from the job file before anything is allocated
+// for them. Offset is the position of the header in the job file.
+func (dec *Decoder) validateAttributeHeader(header *JobAttrHeader) error {
+	offset := dec.offset
+	fields := []struct {
+		name  string
+		size  int32
+		limit int64
+	}{
+		{"name", header.Name, JobAttrNameLimit},
+		{"resource", header.Resource, JobAttrNameLimit},
+		{"value", header.Value, JobAttrValueLimit},
 	}
-	attr.Resource = string(bytes.Trim(dec.b, "\x00"))
 
-	// value is suffixed with 2 bytes; the last may not be null
-	dec.b = dec.b[:header.Value]
-	if _, err := io.ReadFull(dec.r, dec.b); err != nil {
-		return fmt.Errorf("reading job attribute value: %w", err)
+	length := int64(jobAttrHeaderSize)
+	for _, field := range fields {
+		if field.size < 0 || int64(field.size) > field.limit {
+			return &ErrInvalidJobAttrHeader{Offset: offset, Field: field.name, Size: int64(field.size), Limit: field.limit}
+		}
+		length += int64(field.size)
 	}
-	attr.Value = string(bytes.Trim(dec.b[:max(0, len(dec.b)-1)], "\x00"))
 
-	seek := header.Length - int32(jobAttrHeaderSize) - header.Name - header.Resource - header.Value
-	if _, err := dec.r.Discard(int(seek)); err != nil {
-		return fmt.Errorf("failed to seek to next attribute: %w", err)
+	// length covers the header, fields and padding up to the next attribute
+	limit := length + JobAttrValueLimit
+	if dec.size >= 0 {
+		limit = min(limit, dec.size-offset)
+	}
+	if int64(header.Length) < length || int64(header.Length) > limit {
+		return &ErrInvalidJobAttrHeader{Offset: offset, Field: "length", Size: int64(header.Length), Limit: limit}
 	}
 
 	return nil
@@ -179,31 +442,102 @@ func (dec *Decoder) decodeAttribute(job *Job, header *JobAttrHeader, attr *JobAt
 		return err
 	}
 
-	if err := dec.decodeAttributeValue(job, attr); err != nil {
+	if _, err := dec.decodeAttributeValue(job, []byte(attr.Name), []byte(attr.Resource), []byte(attr.Value)); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func (dec *Decoder) Decode(job *Job) error {
-	_, err := dec.r.Discard(JobAttrStartPos - jobAttrHeaderSize)
+func (dec *Decoder) seekAttributes() error {
+	// peek returns the whole file with io.EOF when smaller than the limit
+	prefix, err := dec.r.Peek(jobFileSearchLimit)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("reading job file prefix: %w", err)
+	}
+
+	start, err := jobAttrHeaderPos(prefix)
 	if err != nil {
-		return fmt.Errorf("seeking to start position %v: %w", JobAttrStartPos, err)
+		return err
+	}
+	if dec.file != nil {
+		dec.file.Header = bytes.Clone(prefix[:start])
+	}
+	if _, err := dec.r.Discard(start); err != nil {
+		return fmt.Errorf("seeking to start position %v: %w", start, err)
 	}
+	dec.offset = int64(start)
 
-	header := new(JobAttrHeader)
-	attr := new(JobAttr)
-	for {
-		if err := dec.decodeAttribute(job, header, attr); err != nil {
-			if err == io.EOF {
-				break
+	return nil
+}
+
+// Attributes returns an iterator over the raw job file attributes in the order
+// they are stored, without mapping them onto Job. Iteration ends after the
+// first error.
+func (dec *Decoder) Attributes() iter.Seq2[JobAttr, error] {
+	return func(yield func(JobAttr, error) bool) {
+		if err := dec.seekAttributes(); err != nil {
+			yield(JobAttr{}, err)
+			return
+		}
+
+		header := new(JobAttrHeader)
+		for {
+			var attr JobAttr
+			if err := dec.decodeAttributeHeader(header, &attr); err != nil {
+				if err != io.EOF {
+					yield(JobAttr{}, fmt.Errorf("attribute decode failure: %w", err))
+				}
+				return
+			}
+			if !yield(attr, nil) {
+				return
 			}
-			return fmt.Errorf("attribute decode failure: %w", err)
 		}
 	}
+}
 
-	return nil
+// Decode sets the job attributes of job, keeping the remainder of the job file
+// in job.File so that Encode reproduces the original file.
+func (dec *Decoder) Decode(job *Job) error {
+	job.File = nil
+	job.Flags = nil
+
+	if dec.keepFile {
+		job.File = &JobFile{}
+		dec.file = job.File
+		defer func() { dec.file = nil }()
+	}
+
+	if err := dec.seekAttributes(); err != nil {
+		return err
+	}
+
+	for {
+		name, resource, value, err := dec.readAttribute(&dec.header)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("attribute decode failure: %w", err)
+		}
+
+		setter, err := dec.decodeAttributeValue(job, name, resource, value)
+		if err != nil {
+			return fmt.Errorf("attribute decode failure: %w", err)
+		}
+
+		// known attributes reuse the keys of the setter table
+		if dec.header.Flags != 0 {
+			if job.Flags == nil {
+				job.Flags = make(map[JobMapKey]int32)
+			}
+			key := JobMapKey{Name: string(name), Resource: string(resource)}
+			if setter != nil {
+				key = setter.key
+			}
+			job.Flags[key] = dec.header.Flags
+		}
+	}
 }
 
 func Unmarshal(data []byte, job *Job) error {
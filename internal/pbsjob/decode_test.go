@@ -3,6 +3,7 @@ package pbsjob
 import (
 	"bytes"
 	"encoding/hex"
+	"errors"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -95,6 +96,44 @@ func TestDecodeAttribute(t *testing.T) {
 	}
 }
 
+func TestValidateAttributeHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header JobAttrHeader
+		size   int64
+		field  string
+	}{
+		{"Valid", JobAttrHeader{Length: 119, Name: 9, Value: 6}, -1, ""},
+		{"Valid sized", JobAttrHeader{Length: 119, Name: 9, Value: 6}, 119, ""},
+		{"Negative name", JobAttrHeader{Length: 119, Name: -9, Value: 6}, -1, "name"},
+		{"Negative value", JobAttrHeader{Length: 119, Name: 9, Value: -6}, -1, "value"},
+		{"Large resource", JobAttrHeader{Length: 119, Name: 9, Resource: JobAttrNameLimit + 1}, -1, "resource"},
+		{"Large value", JobAttrHeader{Length: 119, Name: 9, Value: JobAttrValueLimit + 1}, -1, "value"},
+		{"Short length", JobAttrHeader{Length: 24, Name: 9, Value: 6}, -1, "length"},
+		{"Large length", JobAttrHeader{Length: 1 << 30, Name: 9, Value: 6}, -1, "length"},
+		{"Beyond file", JobAttrHeader{Length: 119, Name: 9, Value: 6}, 100, "length"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			dec := NewDecoder(&bytes.Buffer{})
+			dec.size = test.size
+
+			var headerErr *ErrInvalidJobAttrHeader
+			err := dec.validateAttributeHeader(&test.header)
+			if test.field == "" {
+				if err != nil {
+					tt.Errorf("validateAttributeHeader(%+v) returned error: %v", test.header, err)
+				}
+				return
+			}
+			if !errors.As(err, &headerErr) || headerErr.Field != test.field {
+				tt.Errorf("validateAttributeHeader(%+v) = %v, want %s error", test.header, err, test.field)
+			}
+		})
+	}
+}
+
 func TestDecode(t *testing.T) {
 	// test decode with mock binary file
 	t.Run("Decode", func(tt *testing.T) {
@@ -114,11 +153,77 @@ func TestDecode(t *testing.T) {
 		if err := dec.Decode(gotJob); err != nil {
 			t.Fatalf("Decode() returned error: %v", err)
 		}
+		if gotJob.File == nil {
+			t.Fatalf("Decode(%s) did not keep the job file", jobFile)
+		}
+		gotJob.File = nil
 		if !reflect.DeepEqual(gotJob, wantJob) {
 			t.Errorf("Decode(%s) = %+v, want %+v", jobFile, gotJob, wantJob)
 		}
 	})
 
+	// attributes moved by a change to the fixed portion of the job file
+	t.Run("Shifted", func(tt *testing.T) {
+		wantJob := new(Job)
+		if err := loadJobJson("job_1000.json", wantJob); err != nil {
+			tt.Fatalf("load mock job from json: %v", err)
+		}
+		content, err := os.ReadFile("testdata/job_1000.bin")
+		if err != nil {
+			tt.Fatalf("load mock binary job file: %v", err)
+		}
+		shifted := append(make([]byte, 64), content...)
+
+		gotJob := new(Job)
+		if err := NewDecoder(bytes.NewReader(shifted)).Decode(gotJob); err != nil {
+			tt.Fatalf("Decode() returned error: %v", err)
+		}
+		gotJob.File = nil
+		if !reflect.DeepEqual(gotJob, wantJob) {
+			tt.Errorf("Decode(shifted) = %+v, want %+v", gotJob, wantJob)
+		}
+	})
+
+	t.Run("Flags", func(tt *testing.T) {
+		want := map[JobMapKey]int32{
+			{Name: "Job_Name", Resource: ""}:           JobAttrFlagSet,
+			{Name: "Resource_List", Resource: "ngpus"}: JobAttrFlagSet | JobAttrFlagDefault,
+		}
+		content, err := Marshal(&Job{JobName: "name", Flags: want})
+		if err != nil {
+			tt.Fatalf("Marshal() returned error: %v", err)
+		}
+
+		job := &Job{Flags: map[JobMapKey]int32{{Name: "stale"}: JobAttrFlagSet}}
+		if err := Unmarshal(content, job); err != nil {
+			tt.Fatalf("Unmarshal() returned error: %v", err)
+		}
+		if !reflect.DeepEqual(job.Flags, want) {
+			tt.Errorf("Unmarshal().Flags = %v, want %v", job.Flags, want)
+		}
+	})
+
+	t.Run("Oversized trailer", func(tt *testing.T) {
+		content, err := os.ReadFile("testdata/job_1000.bin")
+		if err != nil {
+			tt.Fatalf("load mock binary job file: %v", err)
+		}
+		oversized := append(content, make([]byte, JobAttrValueLimit+1)...)
+
+		err = NewDecoder(bytes.NewReader(oversized)).Decode(new(Job))
+		if err == nil || !strings.Contains(err.Error(), "trailer exceeds limit") {
+			tt.Errorf("Decode(oversized trailer) = %v, want trailer limit error", err)
+		}
+	})
+
+	t.Run("Unknown layout", func(tt *testing.T) {
+		var layoutErr *ErrUnknownJobFileLayout
+		err := NewDecoder(bytes.NewReader(make([]byte, 2048))).Decode(new(Job))
+		if !errors.As(err, &layoutErr) {
+			tt.Errorf("Decode(zeroes) = %v, want %T", err, layoutErr)
+		}
+	})
+
 	// check files in testdata for new PBS attributes
 	t.Run("Strict", func(tt *testing.T) {
 		jobFileDir := "./testdata/jobfiles"
@@ -145,6 +250,123 @@ func TestDecode(t *testing.T) {
 	})
 }
 
+func TestDecoderReset(t *testing.T) {
+	job1, jb1, _ := generateJobFile("job1000", "1000.pbs", 0)
+	job2, jb2, _ := generateJobFile("job1001", "1001.pbs", 0)
+
+	dec := NewDecoder(nil)
+	dec.setKeepFile(false)
+	for _, want := range []struct {
+		job     Job
+		content []byte
+	}{{job1, jb1}, {job2, jb2}} {
+		got := new(Job)
+		dec.Reset(bytes.NewReader(want.content))
+		if err := dec.Decode(got); err != nil {
+			t.Fatalf("Decode() after Reset() returned error: %v", err)
+		}
+		if got.File != nil {
+			t.Errorf("Decode() kept job file with setKeepFile(false)")
+		}
+		if got.Hashname != want.job.Hashname || !reflect.DeepEqual(got.VariableList, want.job.VariableList) {
+			t.Errorf("Decode() after Reset() = %+v, want %+v", got, want.job)
+		}
+	}
+}
+
+func TestAttributes(t *testing.T) {
+	job := &Job{JobName: "name"}
+	content, err := Marshal(job)
+	if err != nil {
+		t.Fatalf("Marshal() returned error: %v", err)
+	}
+
+	// append an attribute unknown to Job before the end of attributes
+	custom := JobAttr{Name: "site_custom", Resource: "", Value: "value"}
+	buf := bytes.NewBuffer(bytes.Clone(content[:len(content)-jobAttrHeaderSize]))
+	enc := NewEncoder(buf)
+	if err := enc.encodeAttribute(&JobAttr{Name: custom.Name}, reflect.ValueOf(custom.Value), ""); err != nil {
+		t.Fatalf("encodeAttribute() returned error: %v", err)
+	}
+	buf.Write(content[len(content)-jobAttrHeaderSize:])
+
+	var got []JobAttr
+	for attr, err := range NewDecoder(bytes.NewReader(buf.Bytes())).Attributes() {
+		if err != nil {
+			t.Fatalf("Attributes() returned error: %v", err)
+		}
+		got = append(got, attr)
+	}
+	if len(got) != len(jobMapOrder)+1 {
+		t.Fatalf("Attributes() yielded %d attributes, want %d", len(got), len(jobMapOrder)+1)
+	}
+	if want := (JobAttr{Name: JobAttrSentinel, Value: job.JobName}); got[0] != want {
+		t.Errorf("Attributes() first = %+v, want %+v", got[0], want)
+	}
+	if got[len(got)-1] != custom {
+		t.Errorf("Attributes() last = %+v, want %+v", got[len(got)-1], custom)
+	}
+
+	t.Run("Break", func(tt *testing.T) {
+		count := 0
+		for range NewDecoder(bytes.NewReader(buf.Bytes())).Attributes() {
+			count++
+			break
+		}
+		if count != 1 {
+			tt.Errorf("Attributes() yielded %d attributes after break, want 1", count)
+		}
+	})
+
+	t.Run("Error", func(tt *testing.T) {
+		truncated := buf.Bytes()[:JobAttrStartPos+4]
+		var gotErr error
+		for _, err := range NewDecoder(bytes.NewReader(truncated)).Attributes() {
+			gotErr = err
+		}
+		if gotErr == nil {
+			tt.Errorf("Attributes(truncated) returned no error")
+		}
+	})
+}
+
+func FuzzUnmarshal(f *testing.F) {
+	if content, err := os.ReadFile("testdata/job_1000.bin"); err == nil {
+		f.Add(content)
+	}
+	if content, err := Marshal(&Job{}); err == nil {
+		f.Add(content)
+		f.Add(content[:len(content)/2])
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		job := new(Job)
+		if err := Unmarshal(data, job); err != nil {
+			return
+		}
+		if _, err := Marshal(job); err != nil {
+			t.Errorf("Marshal() of unmarshalled job returned error: %v", err)
+		}
+	})
+}
+
+func FuzzAttributes(f *testing.F) {
+	if content, err := os.ReadFile("testdata/job_1000.bin"); err == nil {
+		f.Add(content)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for attr, err := range NewDecoder(bytes.NewReader(data)).Attributes() {
+			if err != nil {
+				return
+			}
+			if len(attr.Name) > JobAttrNameLimit || len(attr.Value) > JobAttrValueLimit {
+				t.Errorf("Attributes() yielded oversized attribute %s", attr.Name)
+			}
+		}
+	})
+}
+
 func BenchmarkDecode(b *testing.B) {
 	jobFileDir := "./testdata/jobfiles"
 	jobFiles, _ := os.ReadDir(jobFileDir)
@@ -173,3 +395,35 @@ func BenchmarkDecode(b *testing.B) {
 		})
 	}
 }
+
+// Reports allocations per decoded job, with and without a reused decoder.
+func BenchmarkDecodeJob(b *testing.B) {
+	content, err := loadJobBinary("job_1000.bin")
+	if err != nil {
+		b.Fatalf("Failed to read job file: %v", err)
+	}
+
+	b.Run("Unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := Unmarshal(content, new(Job)); err != nil {
+				b.Fatalf("Failed to decode job file: %v", err)
+			}
+		}
+	})
+
+	b.Run("Reuse", func(b *testing.B) {
+		r := bytes.NewReader(content)
+		dec := NewDecoder(r)
+		dec.setKeepFile(false)
+
+		b.ReportAllocs()
+		for b.Loop() {
+			r.Reset(content)
+			dec.Reset(r)
+			if err := dec.Decode(new(Job)); err != nil {
+				b.Fatalf("Failed to decode job file: %v", err)
+			}
+		}
+	})
+}
@@ -75,7 +75,7 @@ func (enc *Encoder) encodeAttribute(attr *JobAttr, v reflect.Value, separator st
 		Name:     int32(attrLength(len(attr.Name), 1)),
 		Resource: int32(attrLength(len(attr.Resource), 1)),
 		Value:    int32(attrLength(len(attr.Value), 2)),
-		Flags:    0,
+		Flags:    attr.Flags,
 		RefCount: 0,
 	}
 	header.Length += header.Name + header.Resource + header.Value
@@ -111,7 +111,111 @@ func (enc *Encoder) encodeAttribute(attr *JobAttr, v reflect.Value, separator st
 	return nil
 }
 
+// Reports whether the attribute value or flags of the job differ from those
+// decoded from the job file.
+func (enc *Encoder) attributeChanged(attr *JobFileAttr, v reflect.Value, separator string, flags int32) (bool, error) {
+	if attr.Flags != flags {
+		return true, nil
+	}
+
+	// compare values after a round trip as parsing may not be lossless
+	decoded := reflect.New(v.Type()).Elem()
+	if err := new(Decoder).parseAttributeValue(attr.Value, decoded, separator); err != nil {
+		return true, nil
+	}
+	want, err := enc.encodeAttributeValue(decoded, separator)
+	if err != nil {
+		return false, err
+	}
+	got, err := enc.encodeAttributeValue(v, separator)
+	if err != nil {
+		return false, err
+	}
+
+	return got != want, nil
+}
+
+// Writes the job file the job was decoded from. Attributes are copied from the
+// job file unless changed on the job, and attributes unknown to Job are kept.
+func (enc *Encoder) encodeFile(job *Job) error {
+	jobVal := reflect.ValueOf(job).Elem()
+
+	if _, err := enc.w.Write(job.File.Header); err != nil {
+		return fmt.Errorf("writing job header: %w", err)
+	}
+
+	written := make(map[JobMapKey]bool, len(job.File.Attrs))
+	for i := range job.File.Attrs {
+		attr := &job.File.Attrs[i]
+		key := JobMapKey{Name: attr.Name, Resource: attr.Resource}
+		v, ok := jobMap[key]
+		if !ok || written[key] {
+			if _, err := enc.w.Write(attr.Raw); err != nil {
+				return fmt.Errorf("writing job attribute: %w", err)
+			}
+			continue
+		}
+		written[key] = true
+
+		value := jobVal.FieldByIndex(v.Index)
+		changed, err := enc.attributeChanged(attr, value, v.Separator, job.Flags[key])
+		if err != nil {
+			return fmt.Errorf("encoding attribute: %w", err)
+		}
+		if !changed {
+			if _, err := enc.w.Write(attr.Raw); err != nil {
+				return fmt.Errorf("writing job attribute: %w", err)
+			}
+			continue
+		}
+
+		newAttr := &JobAttr{Name: key.Name, Resource: key.Resource, Flags: job.Flags[key]}
+		if err := enc.encodeAttribute(newAttr, value, v.Separator); err != nil {
+			return fmt.Errorf("encoding attribute: %w", err)
+		}
+	}
+
+	// attributes set on the job which were missing from the job file
+	for _, key := range jobMapOrder {
+		v := jobMap[key]
+		value := jobVal.FieldByIndex(v.Index)
+		if written[key] || (value.IsZero() && job.Flags[key] == 0) {
+			continue
+		}
+
+		attr := &JobAttr{Name: key.Name, Resource: key.Resource, Flags: job.Flags[key]}
+		if err := enc.encodeAttribute(attr, value, v.Separator); err != nil {
+			return fmt.Errorf("encoding attribute: %w", err)
+		}
+	}
+
+	if len(job.File.Trailer) == 0 {
+		return enc.encodeEnd()
+	}
+	if _, err := enc.w.Write(job.File.Trailer); err != nil {
+		return fmt.Errorf("writing end of pbs job attributes: %w", err)
+	}
+
+	return nil
+}
+
+func (enc *Encoder) encodeEnd() error {
+	// write PBS specific constant; end of attributes
+	header := JobAttrHeader{Length: JobAttrEndFlag}
+	if err := binary.Write(enc.w, binary.LittleEndian, &header); err != nil {
+		return fmt.Errorf("writing end of pbs job attributes: %w", err)
+	}
+
+	return nil
+}
+
+// Encode writes the job as a job file. Jobs decoded from a job file reproduce
+// that file, with changed attributes re-encoded.
 func (enc *Encoder) Encode(job *Job) error {
+	if job.File != nil {
+		return enc.encodeFile(job)
+	}
+
 	jobVal := reflect.ValueOf(job).Elem()
 
 	// write job header
@@ -125,6 +229,7 @@ func (enc *Encoder) Encode(job *Job) error {
 		attr := &JobAttr{
 			Name:     jobMapOrder[i].Name,
 			Resource: jobMapOrder[i].Resource,
+			Flags:    job.Flags[jobMapOrder[i]],
 		}
 		value := jobVal.FieldByIndex(v.Index)
 
@@ -133,13 +238,7 @@ func (enc *Encoder) Encode(job *Job) error {
 		}
 	}
 
-	// write PBS specific constant; end of attributes
-	header := JobAttrHeader{Length: JobAttrEndFlag}
-	if err := binary.Write(enc.w, binary.LittleEndian, &header); err != nil {
-		return fmt.Errorf("writing end of pbs job attributes: %w", err)
-	}
-
-	return nil
+	return enc.encodeEnd()
 }
 
 func Marshal(job *Job) ([]byte, error) {
@@ -2,10 +2,12 @@ package pbsjob
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/hex"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -101,6 +103,113 @@ func TestEncode(t *testing.T) {
 	}
 }
 
+// Builds a job file attribute as written by PBS, with a reference count,
+// non-null value suffix and padding which the encoder does not write.
+func rawJobAttr(name string, resource string, value string, flags int32) []byte {
+	header := JobAttrHeader{
+		Name:     int32(attrLength(len(name), 1)),
+		Resource: int32(attrLength(len(resource), 1)),
+		Value:    int32(attrLength(len(value), 2)),
+		Flags:    flags,
+		RefCount: 1,
+	}
+	header.Length = int32(jobAttrHeaderSize) + header.Name + header.Resource + header.Value + 8
+	b, _ := binary.Append(nil, binary.LittleEndian, &header)
+	if name != "" {
+		b = append(append(b, name...), 0)
+	}
+	if resource != "" {
+		b = append(append(b, resource...), 0)
+	}
+	if value != "" {
+		b = append(append(b, value...), 0, 'x')
+	}
+
+	return append(b, bytes.Repeat([]byte{0xff}, 8)...)
+}
+
+func TestEncodeLossless(t *testing.T) {
+	header := binary.LittleEndian.AppendUint32(nil, JobFileVersion2400)
+	header = append(header, bytes.Repeat([]byte{0xab}, JobAttrStartPos-jobAttrHeaderSize-len(header))...)
+	trailer, _ := binary.Append(nil, binary.LittleEndian, &JobAttrHeader{Length: JobAttrEndFlag, Flags: 7})
+	content := slices.Concat(
+		header,
+		rawJobAttr("Job_Name", "", "name", JobAttrFlagSet),
+		rawJobAttr("Resource_List", "mem", "8gb", JobAttrFlagSet|JobAttrFlagDefault),
+		rawJobAttr("site_custom", "", "custom", JobAttrFlagSet),
+		rawJobAttr("Variable_List", "", "A=1,B=2", JobAttrFlagSet),
+		trailer,
+	)
+
+	t.Run("Unchanged", func(tt *testing.T) {
+		job := new(Job)
+		if err := Unmarshal(content, job); err != nil {
+			tt.Fatalf("Unmarshal() returned error: %v", err)
+		}
+		got, err := Marshal(job)
+		if err != nil {
+			tt.Fatalf("Marshal() returned error: %v", err)
+		}
+		if !bytes.Equal(got, content) {
+			tt.Errorf("Marshal(Unmarshal(content)) = %x, want %x", got, content)
+		}
+	})
+
+	t.Run("Fixture", func(tt *testing.T) {
+		want, err := loadJobBinary("job_1000.bin")
+		if err != nil {
+			tt.Fatalf("load mock binary job file: %v", err)
+		}
+		job := new(Job)
+		if err := Unmarshal(want, job); err != nil {
+			tt.Fatalf("Unmarshal() returned error: %v", err)
+		}
+		got, err := Marshal(job)
+		if err != nil {
+			tt.Fatalf("Marshal() returned error: %v", err)
+		}
+		if !bytes.Equal(got, want) {
+			tt.Errorf("Marshal(Unmarshal(job_1000.bin)) = %d bytes, want %d", len(got), len(want))
+		}
+	})
+
+	t.Run("Edited", func(tt *testing.T) {
+		job := new(Job)
+		if err := Unmarshal(content, job); err != nil {
+			tt.Fatalf("Unmarshal() returned error: %v", err)
+		}
+		job.JobName = "edited"
+		job.Project = "project"
+		job.Flags[JobMapKey{Name: "project"}] = JobAttrFlagSet
+
+		got, err := Marshal(job)
+		if err != nil {
+			tt.Fatalf("Marshal() returned error: %v", err)
+		}
+		if !bytes.HasPrefix(got, header) || !bytes.HasSuffix(got, trailer) {
+			tt.Errorf("Marshal() did not keep the job file header and trailer")
+		}
+
+		want := []JobAttr{
+			{Name: "Job_Name", Value: "edited", Flags: JobAttrFlagSet},
+			{Name: "Resource_List", Resource: "mem", Value: "8gb", Flags: JobAttrFlagSet | JobAttrFlagDefault},
+			{Name: "site_custom", Value: "custom", Flags: JobAttrFlagSet},
+			{Name: "Variable_List", Value: "A=1,B=2", Flags: JobAttrFlagSet},
+			{Name: "project", Value: "project", Flags: JobAttrFlagSet},
+		}
+		var gotAttrs []JobAttr
+		for attr, err := range NewDecoder(bytes.NewReader(got)).Attributes() {
+			if err != nil {
+				tt.Fatalf("Attributes() returned error: %v", err)
+			}
+			gotAttrs = append(gotAttrs, attr)
+		}
+		if !slices.Equal(gotAttrs, want) {
+			tt.Errorf("Attributes(Marshal()) = %+v, want %+v", gotAttrs, want)
+		}
+	})
+}
+
 func BenchmarkEncode(b *testing.B) {
 	jobFileDir := "./testdata/jobfiles"
 	jobFiles, _ := os.ReadDir(jobFileDir)
@@ -19,6 +19,26 @@ type JobMapValue struct {
 	Separator string
 }
 
+// Sets a field from the bytes of an attribute value.
+type valueSetter func(v reflect.Value, value []byte) error
+
+// Sets a job field from the bytes of an attribute value.
+type fieldSetter func(job *Job, value []byte) error
+
+// Precompiled setter of a job field, built once from the job map so decoding
+// writes to the field at its offset in Job without reflection.
+type jobSetter struct {
+	key JobMapKey
+	set fieldSetter
+}
+
+func (s *jobSetter) setJob(job *Job, value []byte) error {
+	return s.set(job, value)
+}
+
+// Fixed portion of the job file written by PBS ahead of the attributes. Only
+// Version is at the same position in every release; the remaining fields
+// follow the JobFileVersion2400 layout.
 type JobHeader struct {
 	Version  int32
 	Flags    int32
@@ -39,6 +59,21 @@ type JobAttr struct {
 	Name     string
 	Resource string
 	Value    string
+	Flags    int32
+}
+
+// Parts of a decoded job file which are not represented by Job fields.
+type JobFile struct {
+	Header  []byte // bytes ahead of the first attribute
+	Attrs   []JobFileAttr
+	Trailer []byte // end of attributes header and anything after it
+}
+
+// Attribute as stored in the job file, including the attribute header,
+// unused value byte and padding.
+type JobFileAttr struct {
+	JobAttr
+	Raw []byte
 }
 
 const (
@@ -48,8 +83,44 @@ const (
 	JobAttrStartPos = 1120
 )
 
+// Attribute flags (ATR_VFLAG_*) stored with each job file attribute.
+const (
+	JobAttrFlagSet      = 0x01
+	JobAttrFlagModify   = 0x02
+	JobAttrFlagDefault  = 0x04
+	JobAttrFlagModCache = 0x08
+)
+
+// Limits on attribute sizes read from job files. Values hold the submission
+// environment (Variable_List) so may be far larger than names.
+const (
+	JobAttrNameLimit  = 1 << 10
+	JobAttrValueLimit = 4 << 20
+)
+
+// Job file versions (ji_jsversion) written by PBS Pro/OpenPBS.
+const (
+	JobFileVersion2400 = 2400 // 2022.x onwards
+)
+
+// Largest job file prefix searched for the first attribute.
+const jobFileSearchLimit = 4096
+
+type JobFileLayout struct {
+	// Position of the first attribute name.
+	AttrStartPos int
+	Release      string
+}
+
+// Job file versions with a fixed layout. Only 2400 has one verified against
+// job files; older versions are found by searching for the sentinel.
+var jobFileLayouts = map[int32]JobFileLayout{
+	JobFileVersion2400: {AttrStartPos: JobAttrStartPos, Release: "2022.x+"},
+}
+
 var (
 	jobMap, jobMapOrder = NewJobMapCache(reflect.TypeOf(Job{}))
+	jobSetters          = newJobSetters(reflect.TypeOf(Job{}), jobMap)
 	jobAttrHeaderSize   = binary.Size(JobAttrHeader{})
 )
 
@@ -60,6 +131,23 @@ func NewJobMapCache(t reflect.Type) (JobMap, []JobMapKey) {
 	return cache, order
 }
 
+// Returns setters of the job map keyed by attribute name then resource, so
+// lookups by attribute bytes do not allocate.
+func newJobSetters(t reflect.Type, cache JobMap) map[string]map[string]*jobSetter {
+	setters := make(map[string]map[string]*jobSetter)
+	for key, value := range cache {
+		if setters[key.Name] == nil {
+			setters[key.Name] = make(map[string]*jobSetter)
+		}
+		setters[key.Name][key.Resource] = &jobSetter{
+			key: key,
+			set: newFieldSetter(t, value.Index, value.Separator),
+		}
+	}
+
+	return setters
+}
+
 func NewJobMapKey(path []string) (JobMapKey, error) {
 	key := JobMapKey{}
 
@@ -88,9 +176,11 @@ func buildJobMapCache(t reflect.Type, cache JobMap, parent []string, path []int)
 	var name string
 	var separator string
 	for i := 0; i < t.NumField(); i++ {
-		// get field tag
+		// get field tag; fields tagged "-" are not job attributes
 		field := t.Field(i)
-		if tag, ok := field.Tag.Lookup("pbs"); ok && tag != "" {
+		if tag, ok := field.Tag.Lookup("pbs"); ok && tag == "-" {
+			continue
+		} else if ok && tag != "" {
 			name = tag
 		} else {
 			name = t.Field(i).Name
@@ -135,6 +225,30 @@ func findJobAttrStartPos(contents []byte, sentinel []byte) (int64, error) {
 	return int64(start), nil
 }
 
+// Returns the position of the first attribute header in a job file prefix. The
+// layout of the job file version is used when it is known and starts with the
+// sentinel attribute, otherwise the prefix is searched for the sentinel.
+func jobAttrHeaderPos(prefix []byte) (int, error) {
+	header := JobHeader{}
+	if _, err := binary.Decode(prefix, binary.LittleEndian, &header); err != nil {
+		return 0, fmt.Errorf("reading header: %w", err)
+	}
+
+	sentinel := []byte(JobAttrSentinel)
+	if layout, ok := jobFileLayouts[header.Version]; ok {
+		if bytes.HasPrefix(prefix[min(layout.AttrStartPos, len(prefix)):], sentinel) {
+			return layout.AttrStartPos - jobAttrHeaderSize, nil
+		}
+	}
+
+	start, err := findJobAttrStartPos(prefix, sentinel)
+	if err != nil || start < int64(jobAttrHeaderSize) {
+		return 0, &ErrUnknownJobFileLayout{Version: header.Version}
+	}
+
+	return int(start) - jobAttrHeaderSize, nil
+}
+
 func attrLength(length int, padding int) int {
 	if length > 0 {
 		return length + padding
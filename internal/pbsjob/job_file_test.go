@@ -93,7 +93,8 @@ func TestJobMapCache(t *testing.T) {
 		ResourceList struct {
 			Mem int `pbs:"mem"`
 		} `pbs:"Resource_List"`
-		Binding []string `pbs:"binding" sep:":"`
+		Binding []string            `pbs:"binding" sep:":"`
+		Flags   map[JobMapKey]int32 `pbs:"-"`
 	}
 
 	wantCache := JobMap{}
@@ -125,6 +126,44 @@ func TestJobMapCache(t *testing.T) {
 	}
 }
 
+func TestJobSetters(t *testing.T) {
+	for key, value := range jobMap {
+		setter, ok := jobSetters[key.Name][key.Resource]
+		if !ok {
+			t.Errorf("jobSetters missing attribute %s.%s", key.Name, key.Resource)
+			continue
+		}
+		if setter.key != key {
+			t.Errorf("jobSetters[%s][%s] key = %+v, want %+v", key.Name, key.Resource, setter.key, key)
+		}
+
+		// the setter writes the same field as setting it through reflection
+		field := reflect.TypeOf(Job{}).FieldByIndex(value.Index)
+		attrValue := []byte("42")
+		if field.Type.Kind() == reflect.Slice {
+			attrValue = []byte("4" + value.Separator + "2")
+		}
+		got, want := new(Job), new(Job)
+		if err := setter.setJob(got, attrValue); err != nil {
+			t.Errorf("jobSetters[%s][%s].setJob(%s) returned error: %v", key.Name, key.Resource, attrValue, err)
+			continue
+		}
+		newValueSetter(field.Type, value.Separator)(reflect.ValueOf(want).Elem().FieldByIndex(value.Index), attrValue)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("jobSetters[%s][%s].setJob(%s) = %+v, want %+v", key.Name, key.Resource, attrValue, got, want)
+		}
+	}
+
+	job := new(Job)
+	setter := jobSetters["Resource_List"]["mem"]
+	if err := setter.setJob(job, []byte("92gb")); err != nil {
+		t.Fatalf("setJob(92gb) returned error: %v", err)
+	}
+	if want := int64(92 << 30); job.ResourceList.Mem != want {
+		t.Errorf("setJob(92gb) = %d, want %d", job.ResourceList.Mem, want)
+	}
+}
+
 func TestJobAttrAssumptions(t *testing.T) {
 	jobFileDir := "./testdata/jobfiles"
 	jobFiles, _ := os.ReadDir(jobFileDir)
@@ -146,8 +185,9 @@ func TestJobAttrAssumptions(t *testing.T) {
 			if err != nil {
 				tt.Fatalf("findJobAttrStartPos([]byte, %v): %v", JobAttrSentinel, err)
 			}
-			if start != JobAttrStartPos {
-				tt.Errorf("JobAttrStartPos: job file %s has %d, expected %d", jobFilePath, start, JobAttrStartPos)
+			version := int32(binary.LittleEndian.Uint32(content))
+			if layout, ok := jobFileLayouts[version]; ok && start != int64(layout.AttrStartPos) {
+				tt.Errorf("JobAttrStartPos: job file %s has %d, expected %d", jobFilePath, start, layout.AttrStartPos)
 			}
 		})
 
@@ -180,6 +220,45 @@ func TestJobAttrAssumptions(t *testing.T) {
 	}
 }
 
+func TestJobAttrHeaderPos(t *testing.T) {
+	jobFile := func(version int32, start int) []byte {
+		b := binary.LittleEndian.AppendUint32(nil, uint32(version))
+		b = append(b, make([]byte, start-len(b))...)
+		return append(b, JobAttrSentinel...)
+	}
+	tests := []struct {
+		name   string
+		prefix []byte
+		want   int
+		valid  bool
+	}{
+		{"Known layout", jobFile(JobFileVersion2400, JobAttrStartPos), JobAttrStartPos - jobAttrHeaderSize, true},
+		{"Known layout moved", jobFile(JobFileVersion2400, 1200), 1200 - jobAttrHeaderSize, true},
+		{"Version without layout", jobFile(1900, 1000), 1000 - jobAttrHeaderSize, true},
+		{"Unknown version", jobFile(9999, 1500), 1500 - jobAttrHeaderSize, true},
+		{"Missing sentinel", make([]byte, JobAttrStartPos), 0, false},
+		{"Truncated header", []byte{0x60, 0x09}, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(tt *testing.T) {
+			got, err := jobAttrHeaderPos(test.prefix)
+			if err != nil {
+				if test.valid {
+					tt.Fatalf("jobAttrHeaderPos([]byte) returned error: %v", err)
+				}
+				return
+			}
+			if !test.valid {
+				tt.Fatalf("jobAttrHeaderPos([]byte) = %d, want error", got)
+			}
+			if got != test.want {
+				tt.Errorf("jobAttrHeaderPos([]byte) = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
 func TestAttrSize(t *testing.T) {
 	tests := []struct {
 		length  int
@@ -255,5 +334,8 @@ func TestUnmarshalMarshal(t *testing.T) {
 		if !reflect.DeepEqual(job1, job2) {
 			t.Errorf("Unmarshal(job) != Unmarshal(Marshal(Unmarshal(Job)))")
 		}
+		if !bytes.Equal(jobBytes, contents) {
+			t.Errorf("Marshal(Unmarshal(%v)) differs from the job file", jobFile.Name())
+		}
 	}
 }
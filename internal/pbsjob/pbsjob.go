@@ -3,12 +3,12 @@ package pbsjob
 import (
 	"fmt"
 	"io/fs"
-	"log"
 	"log/slog"
 	"os"
 	"os/user"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
@@ -96,12 +96,24 @@ type Job struct {
 	RunVersion    string        `pbs:"run_version"`
 	SubmitHost    string        `pbs:"Submit_Host"`
 	Binding       string        `pbs:"binding" sep:":"`
+
+	// Non-zero flags of attributes decoded from the job file.
+	Flags map[JobMapKey]int32 `json:"-" pbs:"-"`
+
+	// Job file the job was decoded from, used by Encode to reproduce it.
+	File *JobFile `json:"-" pbs:"-"`
 }
 
 func (j *Job) JobId() string {
 	return strings.Split(j.Hashname, ".")[0]
 }
 
+// Reports whether the attribute was set in the job file. Jobs without any
+// attribute flags have no attribute set.
+func (j *Job) IsSet(name string, resource string) bool {
+	return j.Flags[JobMapKey{Name: name, Resource: resource}]&JobAttrFlagSet != 0
+}
+
 func (j *Job) JobUsername() string {
 	return j.Euser
 }
@@ -243,6 +255,12 @@ func (j *Job) IsRunning() bool {
 	return j.JobState == "R"
 }
 
+// Jobs suspended by qsig -s suspend or preempted by the scheduler; substates
+// JOB_SUBSTATE_SUSPEND (43) and JOB_SUBSTATE_SCHSUSP (45).
+func (j *Job) IsSuspended() bool {
+	return j.JobState == "S" || j.Substate == "43" || j.Substate == "45"
+}
+
 func (j *Job) Vnode() string {
 	primaryNode := strings.Split(j.ExecVnode, "+")[0]
 
@@ -270,6 +288,23 @@ func getJobFiles(fileSystem fs.FS) ([]string, error) {
 	return jobFiles, nil
 }
 
+// Decodes the job file at path, reusing the buffers of dec.
+func readJobFile(dec *Decoder, path string) (*Job, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	job := &Job{}
+	dec.Reset(f)
+	if err := dec.Decode(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
 func ParseJobFiles(pbsJobPath string, logger *slog.Logger) (map[string]*Job, error) {
 	jobFiles, err := getJobFiles(os.DirFS(pbsJobPath))
 	if err != nil {
@@ -280,31 +315,32 @@ func ParseJobFiles(pbsJobPath string, logger *slog.Logger) (map[string]*Job, err
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	jobs := make(map[string]*Job)
-
-	// Parse job files concurrently, logs and skips any that fail to parse
-	for _, jobFile := range jobFiles {
-		wg.Add(1)
-		go func(jobFile string) {
-			defer wg.Done()
-			jobFilePath := filepath.Join(pbsJobPath, jobFile)
-			content, err := os.ReadFile(jobFilePath)
-			if err != nil {
-				logger.Error("Error reading job file", "file", jobFilePath, "error", err)
-				return
-			}
+	jobs := make(map[string]*Job, len(jobFiles))
+	paths := make(chan string)
+
+	// Parse job files with a worker per CPU each reusing a decoder, logs and
+	// skips any that fail to parse
+	for range min(len(jobFiles), runtime.GOMAXPROCS(0)) {
+		wg.Go(func() {
+			dec := NewDecoder(nil)
+			dec.setKeepFile(false)
+			for jobFilePath := range paths {
+				job, err := readJobFile(dec, jobFilePath)
+				if err != nil {
+					logger.Error("Error parsing job file", "file", jobFilePath, "error", err)
+					continue
+				}
 
-			job := &Job{}
-			if err := Unmarshal(content, job); err != nil {
-				logger.Error("Error parsing job file", "file", jobFilePath, "error", err)
-				return
+				mu.Lock()
+				jobs[job.JobId()] = job
+				mu.Unlock()
 			}
-
-			mu.Lock()
-			jobs[job.JobId()] = job
-			mu.Unlock()
-		}(jobFile)
+		})
 	}
+	for _, jobFile := range jobFiles {
+		paths <- filepath.Join(pbsJobPath, jobFile)
+	}
+	close(paths)
 	wg.Wait()
 
 	return jobs, nil
@@ -313,18 +349,21 @@ func ParseJobFiles(pbsJobPath string, logger *slog.Logger) (map[string]*Job, err
 func NewJobWatcher(path string) (*fsnotify.Watcher, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("creating job watcher: %w", err)
 	}
 
-	err = watcher.Add(path)
-	if err != nil {
-		log.Fatal(err)
+	if err := watcher.Add(path); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("watching job directory %s: %w", path, err)
 	}
 
 	return watcher, nil
 }
 
 func PbsJobEvent(watcher *fsnotify.Watcher, logger *slog.Logger, pbsJobs *JobCache) error {
+	dec := NewDecoder(nil)
+	dec.setKeepFile(false)
+
 	for {
 		select {
 		case event, ok := <-watcher.Events:
@@ -339,26 +378,15 @@ func PbsJobEvent(watcher *fsnotify.Watcher, logger *slog.Logger, pbsJobs *JobCac
 
 			switch op := event.Op; op {
 			case fsnotify.Op(fsnotify.Create), fsnotify.Op(fsnotify.Write):
-				// anonymous function to scope deferral of f.Close()
-				func() {
-					logger.Debug("PBS job file modified", "name", event.Name, "op", op)
-
-					jobFile, err := os.Open(event.Name)
-					if err != nil {
-						logger.Error("Error opening file", "file", event.Name, "error", err)
-						return
-					}
-					defer jobFile.Close()
-
-					job := &Job{}
-					dec := NewDecoder(jobFile)
-					if err := dec.Decode(job); err != nil {
-						logger.Error("Error parsing job file", "file", event.Name, "error", err)
-						return
-					}
-
-					pbsJobs.Set(job.JobId(), job)
-				}()
+				logger.Debug("PBS job file modified", "name", event.Name, "op", op)
+
+				job, err := readJobFile(dec, event.Name)
+				if err != nil {
+					logger.Error("Error parsing job file", "file", event.Name, "error", err)
+					continue
+				}
+
+				pbsJobs.Set(job.JobId(), job)
 			case fsnotify.Op(fsnotify.Remove):
 				logger.Debug("PBS Job file removed", "name", event.Name, "op", op)
 
@@ -371,6 +399,8 @@ func PbsJobEvent(watcher *fsnotify.Watcher, logger *slog.Logger, pbsJobs *JobCac
 			if !ok {
 				return fmt.Errorf("watcher errors channel closed with error: %v", err)
 			}
+			// events may have been lost, e.g. fsnotify.ErrEventOverflow
+			return fmt.Errorf("watching job files: %w", err)
 		}
 	}
 }
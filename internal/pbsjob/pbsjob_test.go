@@ -1,11 +1,14 @@
 package pbsjob
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"slices"
+	"strconv"
 	"testing"
 	"testing/fstest"
 	"time"
@@ -315,6 +318,53 @@ func TestIsRunning(t *testing.T) {
 	}
 }
 
+func TestIsSuspended(t *testing.T) {
+	tests := []struct {
+		state    string
+		substate string
+		want     bool
+	}{
+		{"R", "42", false},
+		{"S", "43", true},
+		{"R", "45", true},
+		{"S", "", true},
+		{"E", "51", false},
+	}
+	for _, test := range tests {
+		job := &Job{JobState: test.state, Substate: test.substate}
+		got := job.IsSuspended()
+		if got != test.want {
+			t.Errorf("IsSuspended(%s, %s) = %v, want %v", test.state, test.substate, got, test.want)
+		}
+	}
+}
+
+func TestIsSet(t *testing.T) {
+	flags := map[JobMapKey]int32{
+		{Name: "Resource_List", Resource: "ncpus"}: JobAttrFlagSet | JobAttrFlagDefault,
+		{Name: "Resource_List", Resource: "ngpus"}: JobAttrFlagModify,
+	}
+	tests := []struct {
+		flags    map[JobMapKey]int32
+		resource string
+		want     bool
+	}{
+		{flags, "ncpus", true},
+		{flags, "ngpus", false},
+		{flags, "mem", false},
+		// jobs without flags, such as those built in code, have nothing set
+		{nil, "mem", false},
+		{map[JobMapKey]int32{}, "ncpus", false},
+	}
+	for _, test := range tests {
+		job := &Job{Flags: test.flags}
+		got := job.IsSet("Resource_List", test.resource)
+		if got != test.want {
+			t.Errorf("IsSet(Resource_List, %s) = %v, want %v", test.resource, got, test.want)
+		}
+	}
+}
+
 func TestVnode(t *testing.T) {
 	job := &Job{}
 	tests := []struct {
@@ -427,6 +477,36 @@ func TestParseJobFiles(t *testing.T) {
 	})
 }
 
+func TestParseJobFilesWorkers(t *testing.T) {
+	tmpdir := t.TempDir()
+	logger := slog.New(slog.DiscardHandler)
+
+	// more job files than workers, with one invalid
+	count := 4*runtime.GOMAXPROCS(0) + 1
+	for i := range count {
+		jobId := strconv.Itoa(1000 + i)
+		_, content, err := generateJobFile("job"+jobId, jobId+".pbs", 0)
+		if err != nil {
+			t.Fatalf("Failed to generate job file: %v", err)
+		}
+		os.WriteFile(filepath.Join(tmpdir, jobId+".pbs.JB"), content, 0644)
+	}
+	os.WriteFile(filepath.Join(tmpdir, "999.pbs.JB"), []byte("invalid"), 0644)
+
+	got, err := ParseJobFiles(tmpdir, logger)
+	if err != nil {
+		t.Fatalf("ParseJobFiles(%s, <logger>) returned error: %v", tmpdir, err)
+	}
+	if len(got) != count {
+		t.Errorf("ParseJobFiles(%s, <logger>) returned %d jobs, want %d", tmpdir, len(got), count)
+	}
+	for jobId, job := range got {
+		if job.JobId() != jobId || job.File != nil {
+			t.Errorf("ParseJobFiles(%s, <logger>)[%s] = %s with job file %v", tmpdir, jobId, job.JobId(), job.File != nil)
+		}
+	}
+}
+
 func TestPbsJobEvent(t *testing.T) {
 	tmp := t.TempDir()
 	logger := slog.Default()
@@ -493,3 +573,31 @@ func TestPbsJobEvent(t *testing.T) {
 		}
 	})
 }
+
+// Reports allocations of parsing a directory of array subjob files, and
+// allocations per decoded job.
+func BenchmarkParseJobFiles(b *testing.B) {
+	const jobs = 1000
+	tmpdir := b.TempDir()
+	logger := slog.New(slog.DiscardHandler)
+
+	for i := range jobs {
+		jobId := fmt.Sprintf("1000[%d]", i)
+		_, content, err := generateJobFile("array", jobId+".pbs", 0)
+		if err != nil {
+			b.Fatalf("Failed to generate job file: %v", err)
+		}
+		os.WriteFile(filepath.Join(tmpdir, jobId+".pbs.JB"), content, 0644)
+	}
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := ParseJobFiles(tmpdir, logger); err != nil {
+			b.Fatalf("Failed to parse job files: %v", err)
+		}
+	}
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(b.N*jobs), "allocs/job")
+}
@@ -0,0 +1,189 @@
+package pbsjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	jobWatcherMinBackoff = time.Second
+	jobWatcherMaxBackoff = 5 * time.Minute
+)
+
+// Health of the job watcher, used to tell when job metrics may be stale.
+type WatcherHealth struct {
+	LastSync time.Time // last time the job cache was synced with the job directory
+	Polling  bool      // job files are polled while the watcher is down
+	Restarts int
+	Up       bool // job files are watched with inotify
+}
+
+// JobTracker keeps the job cache in sync with the job directory. Job files are
+// watched with inotify; when the watcher fails it is restarted with backoff,
+// and the job directory is polled until it is back. Polling is disabled when
+// the poll interval is not positive.
+type JobTracker struct {
+	cache        *JobCache
+	health       WatcherHealth
+	logger       *slog.Logger
+	maxBackoff   time.Duration
+	minBackoff   time.Duration
+	mu           sync.RWMutex
+	path         string
+	pollInterval time.Duration
+}
+
+func NewJobTracker(path string, cache *JobCache, pollInterval time.Duration, logger *slog.Logger) *JobTracker {
+	return &JobTracker{
+		cache:        cache,
+		logger:       logger,
+		maxBackoff:   jobWatcherMaxBackoff,
+		minBackoff:   jobWatcherMinBackoff,
+		path:         path,
+		pollInterval: pollInterval,
+	}
+}
+
+func (t *JobTracker) Health() WatcherHealth {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.health
+}
+
+// Watch tracks job files until the context is cancelled, restarting the
+// watcher whenever it stops.
+func (t *JobTracker) Watch(ctx context.Context) error {
+	backoff := t.minBackoff
+	for {
+		started := time.Now()
+		err := t.watchEvents(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// a watcher which ran for a while failed on its own, not on restart
+		if time.Since(started) > t.maxBackoff {
+			backoff = t.minBackoff
+		}
+		if inotifyUnavailable(err) {
+			t.logger.Warn("Inotify unavailable, polling PBS job files", "error", err, "restart", backoff)
+		} else {
+			t.logger.Error("PBS job watcher stopped, polling job files until restart", "error", err, "restart", backoff)
+		}
+
+		t.setHealth(func(h *WatcherHealth) { h.Polling = t.pollInterval > 0 })
+		t.poll(ctx, backoff)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		t.setHealth(func(h *WatcherHealth) { h.Restarts++ })
+		backoff = min(2*backoff, t.maxBackoff)
+	}
+}
+
+func (t *JobTracker) watchEvents(ctx context.Context) error {
+	watcher, err := NewJobWatcher(t.path)
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// sync after watching starts so changes while the watcher was down are
+	// not missed
+	if err := t.sync(); err != nil {
+		return err
+	}
+	t.setHealth(func(h *WatcherHealth) { h.Up, h.Polling = true, false })
+	defer t.setHealth(func(h *WatcherHealth) { h.Up = false })
+
+	// closing the watcher ends PbsJobEvent
+	stop := context.AfterFunc(ctx, func() { watcher.Close() })
+	defer stop()
+
+	return PbsJobEvent(watcher, t.logger, t.cache)
+}
+
+// Syncs the job cache every poll interval until the duration has passed, or
+// only waits when polling is disabled.
+func (t *JobTracker) poll(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	if t.pollInterval <= 0 {
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		return
+	}
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := t.sync(); err != nil {
+			t.logger.Error("Error polling PBS job files", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sets jobs from every job file and deletes running jobs whose job file has
+// been removed. Job files which fail to parse are kept running as they may be
+// partially written.
+func (t *JobTracker) sync() error {
+	jobs, err := ParseJobFiles(t.path, t.logger)
+	if err != nil {
+		return fmt.Errorf("parsing job files: %w", err)
+	}
+	jobFiles, err := getJobFiles(os.DirFS(t.path))
+	if err != nil {
+		return fmt.Errorf("listing job files: %w", err)
+	}
+
+	for jobId, job := range jobs {
+		t.cache.Set(jobId, job)
+	}
+
+	exists := make(map[string]bool, len(jobFiles))
+	for _, jobFile := range jobFiles {
+		exists[strings.Split(jobFile, ".")[0]] = true
+	}
+	for _, jobId := range t.cache.RunningJobIds() {
+		if !exists[jobId] {
+			t.logger.Debug("PBS job file removed while not watched", "jobid", jobId)
+			t.cache.Delete(jobId)
+		}
+	}
+
+	t.setHealth(func(h *WatcherHealth) { h.LastSync = time.Now() })
+
+	return nil
+}
+
+func (t *JobTracker) setHealth(update func(*WatcherHealth)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	update(&t.health)
+}
+
+// Reports whether inotify instances (EMFILE) or watches (ENOSPC) are
+// exhausted, or inotify is not supported.
+func inotifyUnavailable(err error) bool {
+	return errors.Is(err, syscall.EMFILE) || errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.ENOSYS)
+}
@@ -0,0 +1,168 @@
+package pbsjob
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func newTestJobTracker(path string) *JobTracker {
+	logger := slog.New(slog.DiscardHandler)
+	tracker := NewJobTracker(path, NewJobCache(logger, 60, 15*time.Second), 5*time.Millisecond, logger)
+	tracker.minBackoff = 20 * time.Millisecond
+	tracker.maxBackoff = 50 * time.Millisecond
+
+	return tracker
+}
+
+// Waits for the condition to hold, failing the test after a second.
+func waitFor(t *testing.T, name string, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", name)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestJobTrackerSync(t *testing.T) {
+	tmpdir := t.TempDir()
+	tracker := newTestJobTracker(tmpdir)
+
+	for _, jobId := range []string{"1000", "1001"} {
+		_, content, err := generateJobFile("job"+jobId, jobId+".pbs", 0)
+		if err != nil {
+			t.Fatalf("Failed to generate job file: %v", err)
+		}
+		os.WriteFile(filepath.Join(tmpdir, jobId+".pbs.JB"), content, 0644)
+	}
+	// partially written job files are not ended
+	os.WriteFile(filepath.Join(tmpdir, "1002.pbs.JB"), []byte("partial"), 0644)
+
+	// jobs whose job file was removed while not watched
+	for _, jobId := range []string{"999", "1002"} {
+		job, _, _ := generateJobFile("job"+jobId, jobId+".pbs", 0)
+		tracker.cache.Set(jobId, &job)
+	}
+
+	if err := tracker.sync(); err != nil {
+		t.Fatalf("sync() returned error: %v", err)
+	}
+
+	got := tracker.cache.RunningJobIds()
+	slices.Sort(got)
+	want := []string{"1000", "1001", "1002"}
+	if !slices.Equal(got, want) {
+		t.Errorf("sync() running jobs = %v, want %v", got, want)
+	}
+	if tracker.Health().LastSync.IsZero() {
+		t.Errorf("sync() did not set last sync time")
+	}
+
+	t.Run("Missing directory", func(tt *testing.T) {
+		tracker := newTestJobTracker(filepath.Join(tmpdir, "missing"))
+		if err := tracker.sync(); err == nil {
+			tt.Errorf("sync() did not return error for missing directory")
+		}
+	})
+}
+
+func TestJobTrackerWatch(t *testing.T) {
+	jobPath := filepath.Join(t.TempDir(), "jobs")
+	tracker := newTestJobTracker(jobPath)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- tracker.Watch(ctx) }()
+
+	// the watcher fails without the job directory, and is restarted
+	waitFor(t, "polling with restarts", func() bool {
+		health := tracker.Health()
+		return health.Polling && !health.Up && health.Restarts > 1
+	})
+
+	if err := os.Mkdir(jobPath, 0755); err != nil {
+		t.Fatalf("Failed to create job directory: %v", err)
+	}
+	waitFor(t, "watcher up", func() bool {
+		health := tracker.Health()
+		return health.Up && !health.Polling
+	})
+
+	job, content, err := generateJobFile("job1000", "1000.pbs", 0)
+	if err != nil {
+		t.Fatalf("Failed to generate job file: %v", err)
+	}
+	os.WriteFile(filepath.Join(jobPath, "1000.pbs.JB"), content, 0644)
+	waitFor(t, "job from watcher", func() bool {
+		got, ok := tracker.cache.Get("1000")
+		return ok && got.JobName == job.JobName
+	})
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Watch() returned error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Watch() did not return after the context was cancelled")
+	}
+	if tracker.Health().Up {
+		t.Errorf("Watch() left watcher up after returning")
+	}
+}
+
+func TestJobTrackerWatchWithoutPolling(t *testing.T) {
+	jobPath := filepath.Join(t.TempDir(), "jobs")
+	tracker := newTestJobTracker(jobPath)
+	tracker.pollInterval = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go tracker.Watch(ctx)
+
+	// the watcher is restarted without polling the job directory
+	waitFor(t, "restarts", func() bool {
+		return tracker.Health().Restarts > 1
+	})
+	health := tracker.Health()
+	if health.Polling || !health.LastSync.IsZero() {
+		t.Errorf("Watch() with poll interval 0 polled job files, health = %+v", health)
+	}
+
+	if err := os.Mkdir(jobPath, 0755); err != nil {
+		t.Fatalf("Failed to create job directory: %v", err)
+	}
+	waitFor(t, "watcher up", func() bool {
+		return tracker.Health().Up
+	})
+}
+
+func TestInotifyUnavailable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("creating job watcher: %w", syscall.EMFILE), true},
+		{fmt.Errorf("watching job directory: %w", syscall.ENOSPC), true},
+		{syscall.ENOENT, false},
+		{fsnotify.ErrEventOverflow, false},
+	}
+
+	for _, test := range tests {
+		if got := inotifyUnavailable(test.err); got != test.want {
+			t.Errorf("inotifyUnavailable(%v) = %v, want %v", test.err, got, test.want)
+		}
+	}
+}
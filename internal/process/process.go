@@ -0,0 +1,334 @@
+package process
+
+import (
+	"cmp"
+	"errors"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/procfs"
+)
+
+// Scheduler states always reported, even when no task is in the state.
+var DefaultStates = []string{"running", "sleeping", "uninterruptible", "zombie"}
+
+// File descriptor types always reported, even when no descriptor is open.
+var DefaultFdTypes = []string{"anon_inode", "device", "file", "pipe", "socket", "other"}
+
+var stateNames = map[string]string{
+	"R": "running",
+	"S": "sleeping",
+	"D": "uninterruptible",
+	"Z": "zombie",
+	"T": "stopped",
+	"t": "tracing_stop",
+	"X": "dead",
+	"I": "idle",
+}
+
+type ProcFS struct {
+	fs procfs.FS
+}
+
+type MemoryRollup struct {
+	Pss  uint64
+	Swap uint64
+}
+
+type Process struct {
+	Comm    string
+	Cpu     float64
+	Pid     uint64
+	Rss     uint64
+	State   string
+	Threads int
+	Uid     uint64
+}
+
+func NewProcFS(root string) (*ProcFS, error) {
+	fs, err := procfs.NewFS(root)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProcFS{fs: fs}, nil
+}
+
+// Reads process details from /proc/<pid>/stat, status and comm.
+func (p *ProcFS) Process(pid uint64) (*Process, error) {
+	proc, err := p.fs.Proc(int(pid))
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := proc.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := proc.NewStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	comm, err := proc.Comm()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Process{
+		Comm:    comm,
+		Cpu:     stat.CPUTime(),
+		Pid:     pid,
+		Rss:     status.VmRSS,
+		State:   stat.State,
+		Threads: stat.NumThreads,
+		Uid:     status.UIDs[0],
+	}, nil
+}
+
+// Reads all processes, skipping those that exited since the PIDs were listed.
+func (p *ProcFS) Processes(pids []uint64) ([]*Process, error) {
+	var processes []*Process
+	for _, pid := range pids {
+		process, err := p.Process(pid)
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+// Reads every process on the host, skipping those which can not be read.
+func (p *ProcFS) AllProcesses() ([]*Process, error) {
+	procs, err := p.fs.AllProcs()
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []*Process
+	for _, proc := range procs {
+		process, err := p.Process(uint64(proc.PID))
+		if err != nil {
+			// processes exit while walking, and may be hidden by hidepid
+			if IsExited(err) || errors.Is(err, os.ErrPermission) {
+				continue
+			}
+			return nil, err
+		}
+		processes = append(processes, process)
+	}
+
+	return processes, nil
+}
+
+// Returns the cgroup paths of a process from /proc/<pid>/cgroup.
+func (p *ProcFS) CgroupPaths(pid uint64) ([]string, error) {
+	proc, err := p.fs.Proc(int(pid))
+	if err != nil {
+		return nil, err
+	}
+
+	cgroups, err := proc.Cgroups()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(cgroups))
+	for i, cgroup := range cgroups {
+		paths[i] = cgroup.Path
+	}
+
+	return paths, nil
+}
+
+// Sums Pss and Swap from /proc/<pid>/smaps_rollup of all processes, skipping
+// those that exited since the PIDs were listed.
+func (p *ProcFS) MemoryRollup(pids []uint64) (*MemoryRollup, error) {
+	rollup := &MemoryRollup{}
+	for _, pid := range pids {
+		proc, err := p.fs.Proc(int(pid))
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		smaps, err := proc.ProcSMapsRollup()
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+		rollup.Pss += smaps.Pss
+		rollup.Swap += smaps.Swap
+	}
+
+	return rollup, nil
+}
+
+// Counts tasks by scheduler state from /proc/<pid>/stat. Thread IDs are
+// accepted as /proc/<tid> is accessible despite being absent from listings.
+func (p *ProcFS) States(pids []uint64) (map[string]int, error) {
+	states := make(map[string]int, len(DefaultStates))
+	for _, state := range DefaultStates {
+		states[state] = 0
+	}
+
+	for _, pid := range pids {
+		proc, err := p.fs.Proc(int(pid))
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		stat, err := proc.Stat()
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+		states[StateName(stat.State)]++
+	}
+
+	return states, nil
+}
+
+// Counts open file descriptors by type from the /proc/<pid>/fd link targets.
+func (p *ProcFS) FileDescriptors(pids []uint64) (map[string]int, error) {
+	fdTypes := make(map[string]int, len(DefaultFdTypes))
+	for _, fdType := range DefaultFdTypes {
+		fdTypes[fdType] = 0
+	}
+
+	for _, pid := range pids {
+		proc, err := p.fs.Proc(int(pid))
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		targets, err := proc.FileDescriptorTargets()
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, target := range targets {
+			fdTypes[FdType(target)]++
+		}
+	}
+
+	return fdTypes, nil
+}
+
+// Returns indices of NVIDIA GPUs opened by the processes, from /dev/nvidiaN
+// file descriptors.
+func (p *ProcFS) NvidiaGpus(pids []uint64) ([]int, error) {
+	var gpus []int
+	for _, pid := range pids {
+		proc, err := p.fs.Proc(int(pid))
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		targets, err := proc.FileDescriptorTargets()
+		if err != nil {
+			if IsExited(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, target := range targets {
+			index, found := strings.CutPrefix(target, "/dev/nvidia")
+			if !found {
+				continue
+			}
+			if gpu, err := strconv.Atoi(index); err == nil {
+				gpus = append(gpus, gpu)
+			}
+		}
+	}
+	slices.Sort(gpus)
+
+	return slices.Compact(gpus), nil
+}
+
+// Classifies a file descriptor by its link target, e.g. "socket:[1234]".
+func FdType(target string) string {
+	switch {
+	case strings.HasPrefix(target, "socket:"):
+		return "socket"
+	case strings.HasPrefix(target, "pipe:"):
+		return "pipe"
+	case strings.HasPrefix(target, "anon_inode:"):
+		return "anon_inode"
+	case strings.HasPrefix(target, "/dev/"):
+		return "device"
+	case strings.HasPrefix(target, "/"):
+		return "file"
+	default:
+		return "other"
+	}
+}
+
+// Returns the name of a single character scheduler state.
+func StateName(state string) string {
+	if name, ok := stateNames[state]; ok {
+		return name
+	}
+
+	return "other"
+}
+
+// Returns the union of the top n processes by CPU time and by RSS, ordered by PID.
+func TopProcesses(processes []*Process, n int) []*Process {
+	if n <= 0 {
+		return nil
+	}
+	if len(processes) <= n {
+		return slices.SortedFunc(slices.Values(processes), comparePid)
+	}
+
+	byCpu := slices.SortedFunc(slices.Values(processes), func(a, b *Process) int {
+		return cmp.Compare(b.Cpu, a.Cpu)
+	})
+	byRss := slices.SortedFunc(slices.Values(processes), func(a, b *Process) int {
+		return cmp.Compare(b.Rss, a.Rss)
+	})
+
+	top := append(byCpu[:n:n], byRss[:n]...)
+	slices.SortFunc(top, comparePid)
+
+	return slices.CompactFunc(top, func(a, b *Process) bool {
+		return a.Pid == b.Pid
+	})
+}
+
+func comparePid(a, b *Process) int {
+	return cmp.Compare(a.Pid, b.Pid)
+}
+
+// Reports whether the error is caused by the process exiting.
+func IsExited(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ESRCH)
+}
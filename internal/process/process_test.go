@@ -0,0 +1,304 @@
+package process
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// Writes a minimal /proc/<pid> tree; CPU times are in clock ticks (100 Hz).
+func mockProc(t *testing.T, root string, pid uint64, comm string, state string, ticks int, rssKb int) {
+	t.Helper()
+	procPath := filepath.Join(root, fmt.Sprint(pid))
+	if err := os.MkdirAll(procPath, 0755); err != nil {
+		t.Fatalf("Failed to create proc dir: %v", err)
+	}
+
+	stat := fmt.Sprintf("%d (%s) %s 1 %d %d 0 -1 4194304 100 0 0 0 %d %d 0 0 20 0 2 0 100 1000000 100 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n", pid, comm, state, pid, pid, ticks, ticks)
+	status := fmt.Sprintf("Name:\t%s\nState:\t%s\nPid:\t%d\nUid:\t1000\t1000\t1000\t1000\nVmRSS:\t%d kB\nThreads:\t2\n", comm, state, pid, rssKb)
+	files := map[string]string{
+		"stat":   stat,
+		"status": status,
+		"comm":   comm + "\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(procPath, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestProcess(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProc(t, procRoot, 1000, "python", "R", 100, 1024)
+	procFs, err := NewProcFS(procRoot)
+	if err != nil {
+		t.Fatalf("NewProcFS(%s) returned error: %v", procRoot, err)
+	}
+
+	want := &Process{
+		Comm:    "python",
+		Cpu:     2,
+		Pid:     1000,
+		Rss:     1048576,
+		State:   "R",
+		Threads: 2,
+		Uid:     1000,
+	}
+	got, err := procFs.Process(1000)
+	if err != nil {
+		t.Fatalf("Process(1000) returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Process(1000) = %+v, want %+v", got, want)
+	}
+
+	t.Run("Processes skips exited", func(t *testing.T) {
+		got, err := procFs.Processes([]uint64{1000, 1001})
+		if err != nil {
+			t.Fatalf("Processes() returned error: %v", err)
+		}
+		if len(got) != 1 || got[0].Pid != 1000 {
+			t.Errorf("Processes() = %+v, want [%+v]", got, want)
+		}
+	})
+}
+
+func TestMemoryRollup(t *testing.T) {
+	procRoot := t.TempDir()
+	smapsRollup := "00400000-7ffd1e5f7000 ---p 00000000 00:00 0                          [rollup]\nRss:                1024 kB\nPss:                 512 kB\nSwap:                 16 kB\n"
+	for _, pid := range []uint64{1000, 1001} {
+		mockProc(t, procRoot, pid, "python", "R", 100, 1024)
+		if err := os.WriteFile(filepath.Join(procRoot, fmt.Sprint(pid), "smaps_rollup"), []byte(smapsRollup), 0644); err != nil {
+			t.Fatalf("Failed to write smaps_rollup: %v", err)
+		}
+	}
+	procFs, err := NewProcFS(procRoot)
+	if err != nil {
+		t.Fatalf("NewProcFS(%s) returned error: %v", procRoot, err)
+	}
+
+	want := &MemoryRollup{
+		Pss:  1048576,
+		Swap: 32768,
+	}
+	got, err := procFs.MemoryRollup([]uint64{1000, 1001, 1002})
+	if err != nil {
+		t.Fatalf("MemoryRollup() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MemoryRollup() = %+v, want %+v", got, want)
+	}
+}
+
+func TestStates(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProc(t, procRoot, 1000, "python", "R", 0, 0)
+	mockProc(t, procRoot, 1001, "python", "D", 0, 0)
+	mockProc(t, procRoot, 1002, "python", "D", 0, 0)
+	mockProc(t, procRoot, 1003, "python", "T", 0, 0)
+	procFs, err := NewProcFS(procRoot)
+	if err != nil {
+		t.Fatalf("NewProcFS(%s) returned error: %v", procRoot, err)
+	}
+
+	want := map[string]int{
+		"running":         1,
+		"sleeping":        0,
+		"uninterruptible": 2,
+		"zombie":          0,
+		"stopped":         1,
+	}
+	got, err := procFs.States([]uint64{1000, 1001, 1002, 1003, 1004})
+	if err != nil {
+		t.Fatalf("States() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("States() = %v, want %v", got, want)
+	}
+}
+
+func TestFileDescriptors(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProc(t, procRoot, 1000, "python", "R", 0, 0)
+	fdPath := filepath.Join(procRoot, "1000", "fd")
+	if err := os.MkdirAll(fdPath, 0755); err != nil {
+		t.Fatalf("Failed to create fd dir: %v", err)
+	}
+	targets := []string{"/dev/null", "/home/user/output.log", "socket:[1234]", "socket:[1235]", "pipe:[1236]", "anon_inode:[eventfd]"}
+	for i, target := range targets {
+		if err := os.Symlink(target, filepath.Join(fdPath, fmt.Sprint(i))); err != nil {
+			t.Fatalf("Failed to create fd symlink: %v", err)
+		}
+	}
+	procFs, err := NewProcFS(procRoot)
+	if err != nil {
+		t.Fatalf("NewProcFS(%s) returned error: %v", procRoot, err)
+	}
+
+	want := map[string]int{
+		"anon_inode": 1,
+		"device":     1,
+		"file":       1,
+		"pipe":       1,
+		"socket":     2,
+		"other":      0,
+	}
+	got, err := procFs.FileDescriptors([]uint64{1000, 1001})
+	if err != nil {
+		t.Fatalf("FileDescriptors() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileDescriptors() = %v, want %v", got, want)
+	}
+}
+
+func TestNvidiaGpus(t *testing.T) {
+	procRoot := t.TempDir()
+	for _, pid := range []uint64{1000, 1001} {
+		mockProc(t, procRoot, pid, "python", "R", 0, 0)
+		fdPath := filepath.Join(procRoot, fmt.Sprint(pid), "fd")
+		if err := os.MkdirAll(fdPath, 0755); err != nil {
+			t.Fatalf("Failed to create fd dir: %v", err)
+		}
+		targets := []string{"/dev/nvidiactl", "/dev/nvidia-uvm", fmt.Sprintf("/dev/nvidia%d", pid-998), "/dev/nvidia2"}
+		for i, target := range targets {
+			if err := os.Symlink(target, filepath.Join(fdPath, fmt.Sprint(i))); err != nil {
+				t.Fatalf("Failed to create fd symlink: %v", err)
+			}
+		}
+	}
+	procFs, err := NewProcFS(procRoot)
+	if err != nil {
+		t.Fatalf("NewProcFS(%s) returned error: %v", procRoot, err)
+	}
+
+	want := []int{2, 3}
+	got, err := procFs.NvidiaGpus([]uint64{1000, 1001, 1002})
+	if err != nil {
+		t.Fatalf("NvidiaGpus() returned error: %v", err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("NvidiaGpus() = %v, want %v", got, want)
+	}
+}
+
+func TestFdType(t *testing.T) {
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"socket:[1234]", "socket"},
+		{"pipe:[1234]", "pipe"},
+		{"anon_inode:[eventpoll]", "anon_inode"},
+		{"/dev/pts/0", "device"},
+		{"/scratch/file.dat", "file"},
+		{"net:[4026531840]", "other"},
+	}
+
+	for _, test := range tests {
+		got := FdType(test.target)
+		if got != test.want {
+			t.Errorf("FdType(%s) = %v, want %v", test.target, got, test.want)
+		}
+	}
+}
+
+func TestStateName(t *testing.T) {
+	tests := []struct {
+		state string
+		want  string
+	}{
+		{"R", "running"},
+		{"S", "sleeping"},
+		{"D", "uninterruptible"},
+		{"Z", "zombie"},
+		{"W", "other"},
+		{"", "other"},
+	}
+
+	for _, test := range tests {
+		got := StateName(test.state)
+		if got != test.want {
+			t.Errorf("StateName(%s) = %v, want %v", test.state, got, test.want)
+		}
+	}
+}
+
+func TestAllProcesses(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProc(t, procRoot, 1000, "python", "R", 0, 0)
+	mockProc(t, procRoot, 1001, "python", "S", 0, 0)
+	procFs, err := NewProcFS(procRoot)
+	if err != nil {
+		t.Fatalf("NewProcFS(%s) returned error: %v", procRoot, err)
+	}
+
+	got, err := procFs.AllProcesses()
+	if err != nil {
+		t.Fatalf("AllProcesses() returned error: %v", err)
+	}
+	if len(got) != 2 {
+		t.Errorf("AllProcesses() = %d processes, want 2", len(got))
+	}
+}
+
+func TestCgroupPaths(t *testing.T) {
+	procRoot := t.TempDir()
+	mockProc(t, procRoot, 1000, "python", "R", 0, 0)
+	cgroup := "0::/pbs_jobs.service/jobs/1000.pbs\n"
+	if err := os.WriteFile(filepath.Join(procRoot, "1000", "cgroup"), []byte(cgroup), 0644); err != nil {
+		t.Fatalf("Failed to write cgroup: %v", err)
+	}
+	procFs, err := NewProcFS(procRoot)
+	if err != nil {
+		t.Fatalf("NewProcFS(%s) returned error: %v", procRoot, err)
+	}
+
+	want := []string{"/pbs_jobs.service/jobs/1000.pbs"}
+	got, err := procFs.CgroupPaths(1000)
+	if err != nil {
+		t.Fatalf("CgroupPaths(1000) returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CgroupPaths(1000) = %v, want %v", got, want)
+	}
+}
+
+func TestNewProcFS(t *testing.T) {
+	if _, err := NewProcFS("/nonexistent"); err == nil {
+		t.Error("NewProcFS('/nonexistent') expected error, got nil")
+	}
+}
+
+func TestTopProcesses(t *testing.T) {
+	processes := []*Process{
+		{Pid: 1, Cpu: 10, Rss: 1},
+		{Pid: 2, Cpu: 1, Rss: 10},
+		{Pid: 3, Cpu: 5, Rss: 5},
+		{Pid: 4, Cpu: 0, Rss: 0},
+	}
+
+	tests := []struct {
+		n    int
+		want []uint64
+	}{
+		{0, nil},
+		{1, []uint64{1, 2}},
+		{2, []uint64{1, 2, 3}},
+		{5, []uint64{1, 2, 3, 4}},
+	}
+
+	for _, test := range tests {
+		var got []uint64
+		for _, process := range TopProcesses(processes, test.n) {
+			got = append(got, process.Pid)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("TopProcesses(processes, %d) = %v, want %v", test.n, got, test.want)
+		}
+	}
+}
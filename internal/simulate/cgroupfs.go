@@ -0,0 +1,168 @@
+package simulate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// v1 hierarchies written for jobs; cpu and cpuacct link to cpu,cpuacct.
+	v1Hierarchies = []string{"cpu,cpuacct", "cpuset", "freezer", "memory", "pids"}
+	v2Controllers = "cpuset cpu memory pids"
+	userHz        = 100.0
+)
+
+func (s *Simulator) createCgroupRoot() error {
+	if s.config.CgroupVersion == "v2" {
+		return os.MkdirAll(filepath.Join(s.config.CgroupRoot, s.cgroupPath), 0755)
+	}
+
+	for _, hierarchy := range v1Hierarchies {
+		if err := os.MkdirAll(filepath.Join(s.config.CgroupRoot, hierarchy, s.cgroupPath), 0755); err != nil {
+			return fmt.Errorf("creating cgroup hierarchy: %w", err)
+		}
+	}
+	for _, link := range []string{"cpu", "cpuacct"} {
+		linkPath := filepath.Join(s.config.CgroupRoot, link)
+		if _, err := os.Lstat(linkPath); err == nil {
+			continue
+		}
+		if err := os.Symlink("cpu,cpuacct", linkPath); err != nil {
+			return fmt.Errorf("linking cgroup hierarchy: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Directories of the job cgroup, one per v1 hierarchy.
+func (s *Simulator) cgroupDirs(job *simJob) []string {
+	if s.config.CgroupVersion == "v2" {
+		return []string{filepath.Join(s.config.CgroupRoot, job.cgroup)}
+	}
+
+	dirs := make([]string, len(v1Hierarchies))
+	for i, hierarchy := range v1Hierarchies {
+		dirs[i] = filepath.Join(s.config.CgroupRoot, hierarchy, job.cgroup)
+	}
+
+	return dirs
+}
+
+func (s *Simulator) createCgroup(job *simJob) error {
+	for _, dir := range s.cgroupDirs(job) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("creating job cgroup: %w", err)
+		}
+	}
+
+	return s.writeCgroupUsage(job)
+}
+
+func (s *Simulator) removeCgroup(job *simJob) error {
+	for _, dir := range s.cgroupDirs(job) {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("removing job cgroup: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Simulator) writeCgroupUsage(job *simJob) error {
+	var files map[string]string
+	if s.config.CgroupVersion == "v2" {
+		files = v2CgroupFiles(job)
+	} else {
+		files = v1CgroupFiles(job)
+	}
+
+	for name, content := range files {
+		path := filepath.Join(s.config.CgroupRoot, name)
+		if s.config.CgroupVersion == "v2" {
+			path = filepath.Join(s.config.CgroupRoot, job.cgroup, name)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return fmt.Errorf("writing cgroup file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Files of a v2 job cgroup, keyed by name.
+func v2CgroupFiles(job *simJob) map[string]string {
+	usec := func(seconds float64) uint64 { return uint64(seconds * 1e6) }
+	limit := job.job.ResourceList.Mem
+
+	return map[string]string{
+		"cgroup.controllers": v2Controllers + "\n",
+		"cgroup.events":      "populated 1\nfrozen 0\n",
+		"cgroup.freeze":      "0\n",
+		"cgroup.procs":       "",
+		"cgroup.threads":     "",
+		"cpu.stat": fmt.Sprintf("usage_usec %d\nuser_usec %d\nsystem_usec %d\n",
+			usec(job.cpuUser+job.cpuSys), usec(job.cpuUser), usec(job.cpuSys)),
+		"cpuset.cpus":         job.cpus + "\n",
+		"cpuset.mems":         "0\n",
+		"memory.current":      fmt.Sprintf("%d\n", job.memory),
+		"memory.max":          fmt.Sprintf("%d\n", limit),
+		"memory.swap.current": "0\n",
+		"memory.swap.max":     "0\n",
+		"memory.stat": fmt.Sprintf("anon %d\nfile %d\nfile_mapped %d\nshmem 0\nactive_anon %d\ninactive_anon 0\nactive_file %d\ninactive_file %d\npgfault 0\npgmajfault 0\n",
+			job.memory*3/4, job.memory/4, job.memory/16, job.memory*3/4, job.memory/8, job.memory/8),
+		"pids.current": "0\n",
+		"pids.max":     "max\n",
+	}
+}
+
+// Files of a v1 job cgroup, keyed by path relative to the cgroup root.
+func v1CgroupFiles(job *simJob) map[string]string {
+	files := map[string]string{}
+	add := func(hierarchy string, name string, content string) {
+		files[filepath.Join(hierarchy, job.cgroup, name)] = content
+	}
+
+	for _, hierarchy := range v1Hierarchies {
+		add(hierarchy, "cgroup.procs", "")
+		add(hierarchy, "tasks", "")
+	}
+
+	ncpus := job.job.ResourceList.Ncpus
+	total := uint64((job.cpuUser + job.cpuSys) * 1e9)
+	percpu := make([]string, ncpus)
+	for i := range percpu {
+		percpu[i] = fmt.Sprintf("%d", total/uint64(ncpus))
+	}
+	add("cpu,cpuacct", "cpu.stat", "nr_periods 0\nnr_throttled 0\nthrottled_time 0\n")
+	add("cpu,cpuacct", "cpuacct.stat", fmt.Sprintf("user %d\nsystem %d\n", uint64(job.cpuUser*userHz), uint64(job.cpuSys*userHz)))
+	add("cpu,cpuacct", "cpuacct.usage", fmt.Sprintf("%d\n", total))
+	add("cpu,cpuacct", "cpuacct.usage_percpu", strings.Join(percpu, " ")+"\n")
+
+	add("cpuset", "cpuset.cpus", job.cpus+"\n")
+	add("cpuset", "cpuset.mems", "0\n")
+
+	add("freezer", "freezer.state", "THAWED\n")
+
+	limit := uint64(job.job.ResourceList.Mem)
+	add("memory", "memory.stat", fmt.Sprintf("total_rss %d\ntotal_active_anon %d\ntotal_inactive_anon 0\ntotal_active_file %d\ntotal_inactive_file %d\ntotal_pgfault 0\ntotal_pgmajfault 0\n",
+		job.memory*3/4, job.memory*3/4, job.memory/8, job.memory/8))
+	add("memory", "memory.oom_control", "oom_kill_disable 0\nunder_oom 0\noom_kill 0\n")
+	for _, module := range []string{"memory", "memory.memsw", "memory.kmem", "memory.kmem.tcp"} {
+		usage := job.memory
+		if module != "memory" && module != "memory.memsw" {
+			usage = 0
+		}
+		add("memory", module+".usage_in_bytes", fmt.Sprintf("%d\n", usage))
+		add("memory", module+".max_usage_in_bytes", fmt.Sprintf("%d\n", usage))
+		add("memory", module+".failcnt", "0\n")
+		add("memory", module+".limit_in_bytes", fmt.Sprintf("%d\n", limit))
+	}
+
+	add("pids", "pids.current", "0\n")
+	add("pids", "pids.max", "max\n")
+
+	return files
+}
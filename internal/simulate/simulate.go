@@ -0,0 +1,279 @@
+package simulate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/cgroups"
+	"github.com/0nebody/pbs_exporter/internal/pbsjob"
+)
+
+var (
+	pbsJobPath  = "mom_priv/jobs"
+	pbsServer   = "pbs"
+	simCpus     = 128
+	simQueues   = []string{"batch", "express", "gpu"}
+	simUsers    = []string{"alice", "bob", "carol", "dave"}
+	simNcpus    = []int{1, 2, 4, 8, 16}
+	simWalltime = []int64{3600, 4 * 3600, 12 * 3600, 48 * 3600}
+)
+
+type Config struct {
+	CgroupRoot    string
+	CgroupVersion string
+	Churn         float64 // fraction of jobs ended and replaced each interval
+	Hostname      string
+	Jobs          int
+	Logger        *slog.Logger
+	PbsHome       string
+	Seed          uint64
+}
+
+// Simulator writes a fake mom_priv/jobs tree of job files and the matching job
+// cgroups, and optionally starts and ends jobs over time.
+type Simulator struct {
+	cgroupPath string
+	config     Config
+	jobs       map[int]*simJob
+	nextId     int
+	rand       *rand.Rand
+}
+
+type simJob struct {
+	cgroup  string // job cgroup path relative to the cgroup root
+	cpus    string
+	cpuUser float64
+	cpuSys  float64
+	id      int
+	job     *pbsjob.Job
+	memory  uint64
+	util    float64 // fraction of requested cpus in use
+}
+
+func New(config Config) *Simulator {
+	hookConfig := cgroups.HookConfig{CgroupPrefix: "pbs_jobs"}
+
+	return &Simulator{
+		cgroupPath: hookConfig.CgroupPath(config.CgroupVersion),
+		config:     config,
+		jobs:       make(map[int]*simJob),
+		nextId:     1000,
+		rand:       rand.New(rand.NewPCG(config.Seed, config.Seed)),
+	}
+}
+
+// Creates the job file and cgroup directories with the configured number of
+// running jobs.
+func (s *Simulator) Generate() error {
+	if err := os.MkdirAll(filepath.Join(s.config.PbsHome, pbsJobPath), 0755); err != nil {
+		return fmt.Errorf("creating job directory: %w", err)
+	}
+	if err := s.createCgroupRoot(); err != nil {
+		return err
+	}
+
+	for range s.config.Jobs {
+		if err := s.startJob(); err != nil {
+			return err
+		}
+	}
+	s.config.Logger.Info("Generated simulated jobs", "jobs", len(s.jobs), "pbs_home", s.config.PbsHome, "cgroup_root", s.config.CgroupRoot)
+
+	return nil
+}
+
+// Updates job usage each interval, ending and replacing a fraction of jobs,
+// until the context is cancelled.
+func (s *Simulator) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.step(interval); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Simulator) step(interval time.Duration) error {
+	ended := 0
+	for _, id := range s.jobIds() {
+		job := s.jobs[id]
+		if s.rand.Float64() < s.config.Churn {
+			if err := s.endJob(job); err != nil {
+				return err
+			}
+			ended++
+			continue
+		}
+
+		job.advance(interval, s.rand)
+		if err := s.writeCgroupUsage(job); err != nil {
+			return err
+		}
+	}
+
+	for range ended {
+		if err := s.startJob(); err != nil {
+			return err
+		}
+	}
+	s.config.Logger.Debug("Simulated job churn", "ended", ended, "jobs", len(s.jobs))
+
+	return nil
+}
+
+// Job IDs in order so runs with the same seed are reproducible.
+func (s *Simulator) jobIds() []int {
+	ids := make([]int, 0, len(s.jobs))
+	for id := range s.jobs {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return ids
+}
+
+func (s *Simulator) newJob() *simJob {
+	id := s.nextId
+	s.nextId++
+
+	jobId := strconv.Itoa(id) + "." + pbsServer
+	user := simUsers[s.rand.IntN(len(simUsers))]
+	ncpus := simNcpus[s.rand.IntN(len(simNcpus))]
+	mem := int64(ncpus) * 4 << 30
+	walltime := simWalltime[s.rand.IntN(len(simWalltime))]
+	stime := time.Now().Unix() - s.rand.Int64N(walltime/2)
+	firstCpu := (id * 16) % simCpus
+	host := s.config.Hostname
+	resource := func(name string) pbsjob.JobMapKey {
+		return pbsjob.JobMapKey{Name: "Resource_List", Resource: name}
+	}
+
+	job := &pbsjob.Job{
+		JobName:     fmt.Sprintf("sim-%d", id),
+		JobOwner:    user + "@" + host,
+		JobState:    "R",
+		Queue:       simQueues[s.rand.IntN(len(simQueues))],
+		Server:      pbsServer,
+		ExecHost:    fmt.Sprintf("%s/%d*%d", host, firstCpu, ncpus),
+		ExecVnode:   fmt.Sprintf("(%s[0]:ncpus=%d:mem=%dkb)", host, ncpus, mem>>10),
+		JoinPath:    "n",
+		KeepFiles:   "n",
+		Mtime:       stime,
+		SchedSelect: fmt.Sprintf("1:ncpus=%d:mem=%dgb", ncpus, mem>>30),
+		Stime:       stime,
+		JobDir:      "/home/" + user,
+		Substate:    "42",
+		VariableList: []string{
+			"PBS_O_HOME=/home/" + user,
+			"PBS_O_LOGNAME=" + user,
+			"PBS_O_QUEUE=" + simQueues[0],
+		},
+		Euser:      user,
+		Egroup:     user,
+		Hashname:   jobId,
+		RunCount:   1,
+		Project:    "_pbs_project_default",
+		RunVersion: "1",
+		SubmitHost: host,
+		ResourceList: pbsjob.ResourceList{
+			Mem:      mem,
+			Ncpus:    ncpus,
+			Place:    "pack",
+			Walltime: formatWalltime(walltime),
+		},
+		Flags: map[pbsjob.JobMapKey]int32{
+			{Name: "Job_Name"}:   pbsjob.JobAttrFlagSet,
+			resource("mem"):      pbsjob.JobAttrFlagSet,
+			resource("ncpus"):    pbsjob.JobAttrFlagSet,
+			resource("place"):    pbsjob.JobAttrFlagSet | pbsjob.JobAttrFlagDefault,
+			resource("walltime"): pbsjob.JobAttrFlagSet,
+		},
+	}
+
+	return &simJob{
+		cgroup: filepath.Join(s.cgroupPath, jobId),
+		cpus:   fmt.Sprintf("%d-%d", firstCpu, firstCpu+ncpus-1),
+		id:     id,
+		job:    job,
+		memory: uint64(mem) / 4,
+		util:   0.2 + 0.8*s.rand.Float64(),
+	}
+}
+
+func (s *Simulator) startJob() error {
+	job := s.newJob()
+	if err := s.writeJobFile(job); err != nil {
+		return err
+	}
+	if err := s.createCgroup(job); err != nil {
+		return err
+	}
+	s.jobs[job.id] = job
+
+	return nil
+}
+
+func (s *Simulator) endJob(job *simJob) error {
+	if err := os.Remove(s.jobFilePath(job)); err != nil {
+		return fmt.Errorf("removing job file: %w", err)
+	}
+	if err := s.removeCgroup(job); err != nil {
+		return err
+	}
+	delete(s.jobs, job.id)
+
+	return nil
+}
+
+func (s *Simulator) jobFilePath(job *simJob) string {
+	return filepath.Join(s.config.PbsHome, pbsJobPath, job.job.Hashname+".JB")
+}
+
+// Writes the job file through a temporary file so watchers never read a
+// partial job file.
+func (s *Simulator) writeJobFile(job *simJob) error {
+	content, err := pbsjob.Marshal(job.job)
+	if err != nil {
+		return fmt.Errorf("encoding job %s: %w", job.job.Hashname, err)
+	}
+
+	jobFile := s.jobFilePath(job)
+	tmpFile := filepath.Join(filepath.Dir(jobFile), "."+filepath.Base(jobFile)+".tmp")
+	if err := os.WriteFile(tmpFile, content, 0600); err != nil {
+		return fmt.Errorf("writing job file: %w", err)
+	}
+	if err := os.Rename(tmpFile, jobFile); err != nil {
+		return fmt.Errorf("writing job file: %w", err)
+	}
+
+	return nil
+}
+
+// Accumulates CPU time and varies memory usage of the job over an interval.
+func (j *simJob) advance(interval time.Duration, r *rand.Rand) {
+	ncpus := float64(j.job.ResourceList.Ncpus)
+	used := interval.Seconds() * ncpus * j.util * (0.9 + 0.2*r.Float64())
+	j.cpuUser += used * 0.9
+	j.cpuSys += used * 0.1
+
+	limit := uint64(j.job.ResourceList.Mem)
+	j.memory = min(limit, uint64(float64(j.memory)*(0.95+0.1*r.Float64())))
+}
+
+func formatWalltime(seconds int64) string {
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds%3600/60, seconds%60)
+}
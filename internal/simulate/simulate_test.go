@@ -0,0 +1,106 @@
+package simulate
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/0nebody/pbs_exporter/internal/pbsjob"
+)
+
+func newTestSimulator(t *testing.T, version string, churn float64) *Simulator {
+	dir := t.TempDir()
+	return New(Config{
+		CgroupRoot:    filepath.Join(dir, "cgroup"),
+		CgroupVersion: version,
+		Churn:         churn,
+		Hostname:      "node01",
+		Jobs:          5,
+		Logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+		PbsHome:       filepath.Join(dir, "pbs_home"),
+		Seed:          1,
+	})
+}
+
+func TestGenerate(t *testing.T) {
+	for _, version := range []string{"v1", "v2"} {
+		t.Run(version, func(tt *testing.T) {
+			s := newTestSimulator(tt, version, 0)
+			if err := s.Generate(); err != nil {
+				tt.Fatalf("Generate() returned error: %v", err)
+			}
+
+			jobFiles, err := filepath.Glob(filepath.Join(s.config.PbsHome, pbsJobPath, "*.JB"))
+			if err != nil {
+				tt.Fatalf("Failed to list job files: %v", err)
+			}
+			if len(jobFiles) != s.config.Jobs {
+				tt.Fatalf("Generate() wrote %d job files, want %d", len(jobFiles), s.config.Jobs)
+			}
+
+			for _, job := range s.jobs {
+				contents, err := os.ReadFile(s.jobFilePath(job))
+				if err != nil {
+					tt.Fatalf("Failed to read job file: %v", err)
+				}
+				got := new(pbsjob.Job)
+				if err := pbsjob.Unmarshal(contents, got); err != nil {
+					tt.Fatalf("Unmarshal(%s) returned error: %v", s.jobFilePath(job), err)
+				}
+				if got.Hashname != job.job.Hashname || got.ResourceList.Ncpus != job.job.ResourceList.Ncpus {
+					tt.Errorf("Unmarshal(%s) = %s with %d ncpus, want %s with %d ncpus", s.jobFilePath(job), got.Hashname, got.ResourceList.Ncpus, job.job.Hashname, job.job.ResourceList.Ncpus)
+				}
+
+				for _, dir := range s.cgroupDirs(job) {
+					if _, err := os.Stat(dir); err != nil {
+						tt.Errorf("Job cgroup %s not created: %v", dir, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestStep(t *testing.T) {
+	s := newTestSimulator(t, "v2", 0)
+	if err := s.Generate(); err != nil {
+		t.Fatalf("Generate() returned error: %v", err)
+	}
+
+	job := s.jobs[s.jobIds()[0]]
+	if err := s.step(time.Minute); err != nil {
+		t.Fatalf("step() returned error: %v", err)
+	}
+	if job.cpuUser <= 0 || job.cpuSys <= 0 {
+		t.Errorf("step() cpu usage = %f user, %f system, want positive", job.cpuUser, job.cpuSys)
+	}
+	if job.memory > uint64(job.job.ResourceList.Mem) {
+		t.Errorf("step() memory = %d, want at most %d", job.memory, job.job.ResourceList.Mem)
+	}
+
+	t.Run("Churn", func(tt *testing.T) {
+		s.config.Churn = 1
+		before := s.jobIds()
+		if err := s.step(time.Minute); err != nil {
+			tt.Fatalf("step() returned error: %v", err)
+		}
+
+		if len(s.jobs) != len(before) {
+			tt.Errorf("step() left %d jobs, want %d", len(s.jobs), len(before))
+		}
+		for _, id := range before {
+			if _, ok := s.jobs[id]; ok {
+				tt.Errorf("step() did not end job %d", id)
+			}
+		}
+		if _, err := os.Stat(s.jobFilePath(job)); !os.IsNotExist(err) {
+			tt.Errorf("step() did not remove job file %s", s.jobFilePath(job))
+		}
+		if _, err := os.Stat(s.cgroupDirs(job)[0]); !os.IsNotExist(err) {
+			tt.Errorf("step() did not remove job cgroup %s", s.cgroupDirs(job)[0])
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -22,6 +23,27 @@ func BooleanToInt(b bool) int {
 	return 0
 }
 
+// Builds a job ID pattern for job cgroups directly under cgroupPath, matching
+// the job ID and optional array index as the default pattern does.
+func CgroupJobIdPattern(cgroupPath string) string {
+	return regexp.QuoteMeta(strings.Trim(cgroupPath, "/")) + `\/(\d+(?:\[\d+\])?)(?:\.(\d+))?`
+}
+
+// Replaces PbsJobIdRegex; the first capture group must be the job ID and the
+// optional second group the array index.
+func SetPbsJobIdRegex(pattern string) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid job ID regex '%s': %w", pattern, err)
+	}
+	if re.NumSubexp() < 1 {
+		return fmt.Errorf("job ID regex '%s' has no capture group", pattern)
+	}
+	PbsJobIdRegex = re
+
+	return nil
+}
+
 func GetCgroupJobId(cgroupPath string) string {
 	var jobId, jobIndex string
 
@@ -90,6 +112,30 @@ func ParseListFormat(listFormat string) ([]int, error) {
 	return result, nil
 }
 
+// Formats a list of CPU or memory-node numbers into the compact List Format
+// used by cpuset, e.g. [0 1 2 3 8] becomes "0-3,8".
+func FormatListFormat(list []int) string {
+	sorted := slices.Clone(list)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	var parts []string
+	for i := 0; i < len(sorted); {
+		j := i
+		for j+1 < len(sorted) && sorted[j+1] == sorted[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(sorted[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", sorted[i], sorted[j]))
+		}
+		i = j + 1
+	}
+
+	return strings.Join(parts, ",")
+}
+
 func ParseWalltime(walltime string) int64 {
 	walltimeSeconds := int64(0)
 	if walltime == "" {
@@ -51,6 +51,52 @@ func TestGetCgroupJobId(t *testing.T) {
 	}
 }
 
+func TestCgroupJobIdPattern(t *testing.T) {
+	tests := []struct {
+		cgroupPath string
+		path       string
+		want       string
+	}{
+		{"pbs.slice/jobs", "/sys/fs/cgroup/pbs.slice/jobs/12345.2/child", "12345[1]"},
+		{"/pbs.service/jobid/", "/pbs.service/jobid/12345.pbs", "12345"},
+		{"pbs.service/jobid", "/pbs_jobs.service/jobid/12345.pbs", ""},
+	}
+
+	original := PbsJobIdRegex
+	t.Cleanup(func() { PbsJobIdRegex = original })
+	for _, tt := range tests {
+		t.Run(tt.cgroupPath, func(t *testing.T) {
+			if err := SetPbsJobIdRegex(CgroupJobIdPattern(tt.cgroupPath)); err != nil {
+				t.Fatalf("SetPbsJobIdRegex() returned error: %v", err)
+			}
+			got := GetCgroupJobId(tt.path)
+			if got != tt.want {
+				t.Errorf("GetCgroupJobId(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetPbsJobIdRegex(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{`jobs/(\d+)`, false},
+		{`jobs/\d+`, true},
+		{`jobs/(\d+`, true},
+	}
+
+	original := PbsJobIdRegex
+	t.Cleanup(func() { PbsJobIdRegex = original })
+	for _, tt := range tests {
+		err := SetPbsJobIdRegex(tt.pattern)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SetPbsJobIdRegex(%s) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
+
 func TestMustHostname(t *testing.T) {
 	got := MustHostname()
 	if got == "" {
@@ -97,6 +143,26 @@ func TestParseListFormat(t *testing.T) {
 	}
 }
 
+func TestFormatListFormat(t *testing.T) {
+	tests := []struct {
+		list []int
+		want string
+	}{
+		{nil, ""},
+		{[]int{0}, "0"},
+		{[]int{0, 1, 2, 3, 4}, "0-4"},
+		{[]int{0, 1, 2, 3, 4, 9}, "0-4,9"},
+		{[]int{14, 0, 1, 2, 7, 12, 13, 7}, "0-2,7,12-14"},
+	}
+
+	for _, test := range tests {
+		got := FormatListFormat(test.list)
+		if got != test.want {
+			t.Errorf("FormatListFormat(%v) = %v, want %v", test.list, got, test.want)
+		}
+	}
+}
+
 func TestParseWalltime(t *testing.T) {
 	tests := []struct {
 		walltime string
@@ -1330,6 +1330,8 @@ local prometheusQuery = g.query.prometheus;
     )
     * on(jobid, runcount) group_left(name, username)
     pbs_job_info{state="R"}
+    unless on (jobid, runcount)
+    pbs_cgroup_suspended == 1
     and on (jobid, runcount)
     time() - pbs_job_start_time > %(runtime)s
   ||| % config.thresholds,
@@ -1386,6 +1388,8 @@ local prometheusQuery = g.query.prometheus;
     )
     * on(jobid, runcount) group_left(name, username)
     pbs_job_info{state="R"}
+    unless on (jobid, runcount)
+    pbs_cgroup_suspended == 1
     and on (jobid, runcount)
     time() - pbs_job_start_time > %(runtime)s
   ||| % config.thresholds,
@@ -1437,6 +1441,8 @@ local prometheusQuery = g.query.prometheus;
     )
     * on (jobid, instance) group_left(username, name, queue, runcount)
     pbs_job_info{state="R"}
+    unless on (jobid, runcount)
+    pbs_cgroup_suspended == 1
     and on (jobid, runcount)
     time() - pbs_job_start_time > %(runtime)s
   ||| % config.thresholds,
@@ -1488,6 +1494,8 @@ local prometheusQuery = g.query.prometheus;
     )
     * on(jobid, runcount) group_left(username, name, queue)
     pbs_job_info{state="R"}
+    unless on (jobid, runcount)
+    pbs_cgroup_suspended == 1
     and on (jobid, runcount)
     (
       pbs_job_requested_ncpus >= 2